@@ -19,6 +19,12 @@ type JwtAdminClaims struct {
 	Role     string `json:"role"`
 }
 
+// JwtMemberClaims 会员 claims，与管理员使用不同的实例与密钥
+type JwtMemberClaims struct {
+	jwt.RegisteredClaims
+	MemberID int64 `json:"member_id"`
+}
+
 func main() {
 	// 1. 生成密钥（仅用于演示，生产环境应使用固定密钥）
 	keyHex, err := kitjwt.GenerateKey()
@@ -64,7 +70,37 @@ func main() {
 	fmt.Printf("解析的 Claims: UserID=%d, Username=%s, Role=%s\n",
 		parsedClaims.UserID, parsedClaims.Username, parsedClaims.Role)
 
-	// 5. Gin 中间件使用示例
+	// 5. 注册第二个实例：会员 token 使用独立的密钥与 claims 类型
+	memberKeyHex, err := kitjwt.GenerateKey()
+	if err != nil {
+		log.Fatalf("生成密钥失败: %v", err)
+	}
+	memberKeyring := kitjwt.NewKeyring()
+	if err = memberKeyring.Add("", memberKeyHex); err != nil {
+		log.Fatalf("添加密钥失败: %v", err)
+	}
+	err = kitjwt.Register("member", kitjwt.New(memberKeyring, func() *JwtMemberClaims {
+		return &JwtMemberClaims{}
+	}))
+	if err != nil {
+		log.Fatalf("注册会员 JWT 失败: %v", err)
+	}
+	member, err := kitjwt.Lookup[*JwtMemberClaims]("member")
+	if err != nil {
+		log.Fatalf("获取会员 JWT 失败: %v", err)
+	}
+	memberToken, err := member.Sign(&JwtMemberClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			Subject:   "member",
+		},
+		MemberID: 2001,
+	})
+	if err != nil {
+		log.Fatalf("签名失败: %v", err)
+	}
+
+	// 6. Gin 中间件使用示例
 	r := gin.Default()
 
 	// 公开路由
@@ -92,10 +128,25 @@ func main() {
 		})
 	}
 
+	// 会员路由组使用 "member" 实例，管理员 token 无法通过
+	memberGroup := r.Group("/member")
+	memberGroup.Use(kitctx.GinMiddlewareJwtAuthNamed[*JwtMemberClaims]("member"))
+	{
+		memberGroup.GET("/profile", func(c *gin.Context) {
+			getClaims, err := kitctx.GetClaims[*JwtMemberClaims](c)
+			if err != nil {
+				c.JSON(401, gin.H{"error": "unauthorized"})
+				return
+			}
+			c.JSON(200, gin.H{"member_id": getClaims.MemberID})
+		})
+	}
+
 	fmt.Println("\n启动服务器: http://localhost:8080")
 	fmt.Println("测试命令:")
 	fmt.Printf("  curl http://localhost:8080/login\n")
 	fmt.Printf("  curl -H 'Authorization: Bearer %s' http://localhost:8080/api/profile\n", token)
+	fmt.Printf("  curl -H 'Authorization: Bearer %s' http://localhost:8080/member/profile\n", memberToken)
 
 	// 注意：根据用户规则，这里不实际运行服务器
 	r.Run(":8080")
@@ -29,6 +29,10 @@ func main() {
 		),
 		// 设置 Connect 拦截器（可选，默认已包含 kitcodec.WithProtoJSON()）
 		// kitrouter.WithInterceptors(yourInterceptor),
+		// 替换默认的 JSON 编解码器（可选）
+		// kitrouter.WithCodec(kitcodec.WithProtoJSONOptions(kitcodec.WithUseProtoNames())),
+		// 在 /openapi.json 提供已挂载服务的 OpenAPI 文档（可选）
+		// kitrouter.WithOpenAPI("/openapi.json", kitcodec.WithOpenAPIInfo("Demo API", "1.0.0")),
 	)
 
 	// 2. 注册无需登录的路由
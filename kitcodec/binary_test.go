@@ -0,0 +1,220 @@
+package kitcodec
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func binaryCodecs() []stableCodec {
+	return []stableCodec{&msgPackCodec{}, &cborCodec{}}
+}
+
+func TestBinaryCodecs_RoundTrip(t *testing.T) {
+	const text = `
+user_id: 9007199254740993
+user_name: "alice"
+status: STATUS_BANNED
+active: true
+scores: [1, -2, 3]
+profile: { display_name: "a" tags: ["x", "y"] child: { display_name: "b" } }
+created_at: { seconds: 1700000000 nanos: 500 }
+balance: 18446744073709551615
+friend_ids: [1, 2]
+counters: { key: "k" value: -7 }
+profiles: [{ display_name: "p1" }, { display_name: "p2" }]
+`
+	for _, codec := range binaryCodecs() {
+		t.Run(codec.Name(), func(t *testing.T) {
+			msg := mustUnmarshalText(t, "kitcodec.test.User", text)
+			extra, err := anypb.New(&descriptorpb.EnumValueDescriptorProto{Name: proto.String("X"), Number: proto.Int32(1)})
+			if err != nil {
+				t.Fatalf("anypb.New() error = %v", err)
+			}
+			msg.Set(msg.Descriptor().Fields().ByName("extra"), protoreflect.ValueOfMessage(extra.ProtoReflect()))
+
+			data, err := codec.Marshal(msg)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			out := newTestMessage(t, "kitcodec.test.User")
+			if err = codec.Unmarshal(data, out); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !proto.Equal(out, msg) {
+				t.Fatalf("round trip mismatch:\n got %v\nwant %v", out, msg)
+			}
+			if !codec.IsBinary() {
+				t.Fatalf("IsBinary() = false, want true")
+			}
+		})
+	}
+}
+
+func TestBinaryCodecs_WellKnownTypes(t *testing.T) {
+	value, err := structpb.NewStruct(map[string]any{"a": 1.5, "b": []any{"c", true}})
+	if err != nil {
+		t.Fatalf("structpb.NewStruct() error = %v", err)
+	}
+	wrapped, err := anypb.New(durationpb.New(90 * time.Second))
+	if err != nil {
+		t.Fatalf("anypb.New() error = %v", err)
+	}
+	messages := []proto.Message{
+		durationpb.New(1500 * time.Millisecond),
+		timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)),
+		value,
+		wrapped,
+	}
+	for _, codec := range binaryCodecs() {
+		for _, msg := range messages {
+			data, err := codec.Marshal(msg)
+			if err != nil {
+				t.Fatalf("%s: Marshal(%T) error = %v", codec.Name(), msg, err)
+			}
+			out := msg.ProtoReflect().New().Interface()
+			if err = codec.Unmarshal(data, out); err != nil {
+				t.Fatalf("%s: Unmarshal(%T) error = %v", codec.Name(), msg, err)
+			}
+			if !proto.Equal(out, msg) {
+				t.Fatalf("%s: %T round trip = %v, want %v", codec.Name(), msg, out, msg)
+			}
+		}
+	}
+}
+
+func TestBinaryCodecs_WireShape(t *testing.T) {
+	msg := mustUnmarshalText(t, "kitcodec.test.User", `user_id: 1 status: STATUS_ACTIVE created_at: { seconds: 1700000000 }`)
+	want := map[string]any{
+		"userId":    int64(1),
+		"status":    "STATUS_ACTIVE",
+		"createdAt": time.Unix(1700000000, 0).UTC(),
+	}
+
+	data, err := (&msgPackCodec{}).Marshal(msg)
+	if err != nil {
+		t.Fatalf("msgpack Marshal() error = %v", err)
+	}
+	var gotMsgPack map[string]any
+	if err = msgpack.Unmarshal(data, &gotMsgPack); err != nil {
+		t.Fatalf("msgpack.Unmarshal() error = %v", err)
+	}
+	assertWireShape(t, "msgpack", gotMsgPack, want)
+
+	data, err = (&cborCodec{}).Marshal(msg)
+	if err != nil {
+		t.Fatalf("cbor Marshal() error = %v", err)
+	}
+	var gotCBOR map[string]any
+	if err = cbor.Unmarshal(data, &gotCBOR); err != nil {
+		t.Fatalf("cbor.Unmarshal() error = %v", err)
+	}
+	assertWireShape(t, "cbor", gotCBOR, want)
+}
+
+func assertWireShape(t *testing.T, name string, got, want map[string]any) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: keys = %v, want %v", name, got, want)
+	}
+	for key, w := range want {
+		g, ok := got[key]
+		if !ok {
+			t.Fatalf("%s: missing key %q in %v", name, key, got)
+		}
+		if wt, ok := w.(time.Time); ok {
+			if gt, ok := g.(time.Time); !ok || !gt.Equal(wt) {
+				t.Fatalf("%s: %s = %#v, want %v", name, key, g, wt)
+			}
+			continue
+		}
+		if n, err := toInt64(g); err == nil {
+			g = n
+		}
+		if g != w {
+			t.Fatalf("%s: %s = %#v, want %#v", name, key, g, w)
+		}
+	}
+}
+
+func TestBinaryCodecs_ProtoNamesAndStable(t *testing.T) {
+	msg := mustUnmarshalText(t, "kitcodec.test.User", `user_name: "a" counters: [{ key: "z" value: 1 }, { key: "a" value: 2 }, { key: "m" value: 3 }]`)
+	for _, codec := range binaryCodecs() {
+		first, err := codec.MarshalStable(msg)
+		if err != nil {
+			t.Fatalf("%s: MarshalStable() error = %v", codec.Name(), err)
+		}
+		for i := 0; i < 10; i++ {
+			again, err := codec.MarshalStable(msg)
+			if err != nil {
+				t.Fatalf("%s: MarshalStable() error = %v", codec.Name(), err)
+			}
+			if !bytes.Equal(first, again) {
+				t.Fatalf("%s: MarshalStable() output is not stable", codec.Name())
+			}
+		}
+	}
+
+	data, err := msgpack.Marshal(map[string]any{"user_name": "legacy", "unknown": 1})
+	if err != nil {
+		t.Fatalf("msgpack.Marshal() error = %v", err)
+	}
+	out := newTestMessage(t, "kitcodec.test.User")
+	if err = (&msgPackCodec{}).Unmarshal(data, out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := out.Get(out.Descriptor().Fields().ByName("user_name")).String(); got != "legacy" {
+		t.Fatalf("user_name = %q, want %q", got, "legacy")
+	}
+}
+
+func TestBinaryCodecs_Resolver(t *testing.T) {
+	profile := mustUnmarshalText(t, "kitcodec.test.Profile", `display_name: "bob"`)
+	extra, err := anypb.New(profile)
+	if err != nil {
+		t.Fatalf("anypb.New() error = %v", err)
+	}
+	msg := newTestMessage(t, "kitcodec.test.User")
+	msg.Set(msg.Descriptor().Fields().ByName("extra"), protoreflect.ValueOfMessage(extra.ProtoReflect()))
+
+	types := new(protoregistry.Types)
+	if err = types.RegisterMessage(dynamicpb.NewMessageType(profile.Descriptor())); err != nil {
+		t.Fatalf("RegisterMessage() error = %v", err)
+	}
+	options := binaryCodecOptions{}
+	options.apply([]BinaryCodecOption{WithBinaryResolver(types)})
+	codecs := []stableCodec{&msgPackCodec{options}, &cborCodec{options}}
+	for i, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			if _, err := binaryCodecs()[i].Marshal(msg); err == nil {
+				t.Fatalf("Marshal() with global resolver should fail on unregistered Any type")
+			}
+			data, err := codec.Marshal(msg)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if err = binaryCodecs()[i].Unmarshal(data, newTestMessage(t, "kitcodec.test.User")); err == nil {
+				t.Fatalf("Unmarshal() with global resolver should fail on unregistered Any type")
+			}
+			out := newTestMessage(t, "kitcodec.test.User")
+			if err = codec.Unmarshal(data, out); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !proto.Equal(out, msg) {
+				t.Fatalf("round trip mismatch:\n got %v\nwant %v", out, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package kitcodec
+
+import "sync"
+
+const (
+    // initialBufferSize 缓冲区初始容量
+    initialBufferSize = 1024
+    // maxPooledBufferSize 超过该容量的缓冲区不放回池中, 避免偶发的大消息长期占用内存
+    maxPooledBufferSize = 1 << 20
+)
+
+// bufferPool 复用编码过程中的临时缓冲区
+var bufferPool = sync.Pool{
+    New: func() any {
+        buf := make([]byte, 0, initialBufferSize)
+        return &buf
+    },
+}
+
+func getBuffer() *[]byte {
+    return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(buf *[]byte) {
+    if cap(*buf) > maxPooledBufferSize {
+        return
+    }
+    *buf = (*buf)[:0]
+    bufferPool.Put(buf)
+}
+
+// compactJSON 原地去掉合法 JSON 中字符串以外的空白字符, 返回 data 的前缀
+func compactJSON(data []byte) []byte {
+    w := 0
+    inString := false
+    for r := 0; r < len(data); r++ {
+        b := data[r]
+        if inString {
+            data[w] = b
+            w++
+            switch b {
+            case '\\':
+                r++
+                if r < len(data) {
+                    data[w] = data[r]
+                    w++
+                }
+            case '"':
+                inString = false
+            }
+            continue
+        }
+        switch b {
+        case ' ', '\t', '\n', '\r':
+            continue
+        case '"':
+            inString = true
+        }
+        data[w] = b
+        w++
+    }
+    return data[:w]
+}
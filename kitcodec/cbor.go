@@ -0,0 +1,106 @@
+package kitcodec
+
+import (
+    "bytes"
+    "fmt"
+    "reflect"
+
+    "connectrpc.com/connect"
+    "github.com/fxamacker/cbor/v2"
+    "google.golang.org/protobuf/proto"
+)
+
+const codecNameCBOR = "cbor"
+
+var (
+    // cborEncMode Timestamp 编码为 tag 0 的 RFC3339 时间字符串
+    cborEncMode = mustCBOREncMode(cbor.EncOptions{Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired})
+    // cborStableEncMode 额外按 RFC 8949 核心确定性编码规则排序 map 键
+    cborStableEncMode = mustCBOREncMode(cbor.EncOptions{Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired, Sort: cbor.SortCoreDeterministic})
+    cborDecMode       = mustCBORDecMode(cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))})
+)
+
+// cborCodec 通过 protoreflect 将 proto 消息映射为 CBOR
+type cborCodec struct {
+    binaryCodecOptions
+}
+
+var (
+    _ marshalAppender = (*cborCodec)(nil)
+    _ stableCodec     = (*cborCodec)(nil)
+)
+
+// WithCBOR 注册名为 cbor 的编解码器, Content-Type 为 application/cbor
+func WithCBOR(opts ...BinaryCodecOption) connect.Option {
+    c := &cborCodec{}
+    c.apply(opts)
+    return connect.WithCodec(c)
+}
+
+func (c *cborCodec) Name() string { return codecNameCBOR }
+
+func (c *cborCodec) Marshal(message any) ([]byte, error) {
+    return c.MarshalAppend(nil, message)
+}
+
+func (c *cborCodec) MarshalAppend(dst []byte, message any) ([]byte, error) {
+    return c.marshal(dst, message, cborEncMode)
+}
+
+func (c *cborCodec) MarshalStable(message any) ([]byte, error) {
+    return c.marshal(nil, message, cborStableEncMode)
+}
+
+func (c *cborCodec) marshal(dst []byte, message any, mode cbor.EncMode) ([]byte, error) {
+    protoMessage, ok := message.(proto.Message)
+    if !ok {
+        return nil, errNotProto(message)
+    }
+    value, err := c.mapper().messageToValue(protoMessage.ProtoReflect())
+    if err != nil {
+        return nil, err
+    }
+    buf := bytes.NewBuffer(dst)
+    if err = mode.NewEncoder(buf).Encode(value); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func (c *cborCodec) Unmarshal(binary []byte, message any) error {
+    protoMessage, ok := message.(proto.Message)
+    if !ok {
+        return errNotProto(message)
+    }
+    if len(binary) == 0 {
+        return nil
+    }
+    var value any
+    if err := cborDecMode.Unmarshal(binary, &value); err != nil {
+        return fmt.Errorf("unmarshal into %T: %w", message, err)
+    }
+    if err := c.mapper().valueToMessage(value, protoMessage.ProtoReflect()); err != nil {
+        return fmt.Errorf("unmarshal into %T: %w", message, err)
+    }
+    return nil
+}
+
+func (c *cborCodec) IsBinary() bool {
+    return true
+}
+
+func mustCBOREncMode(opts cbor.EncOptions) cbor.EncMode {
+    mode, err := opts.EncMode()
+    if err != nil {
+        panic(err)
+    }
+    return mode
+}
+
+func mustCBORDecMode(opts cbor.DecOptions) cbor.DecMode {
+    mode, err := opts.DecMode()
+    if err != nil {
+        panic(err)
+    }
+    return mode
+}
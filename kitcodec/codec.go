@@ -1,14 +1,13 @@
 package kitcodec
 
 import (
-    "bytes"
-    "encoding/json"
     "fmt"
 
     "connectrpc.com/connect"
 
     "google.golang.org/protobuf/encoding/protojson"
     "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protoregistry"
     "google.golang.org/protobuf/runtime/protoiface"
 )
 
@@ -79,22 +78,136 @@ type stableCodec interface {
 }
 
 type protoJSONCodec struct {
-    name string
+    name          string
+    marshal       protojson.MarshalOptions
+    unmarshal     protojson.UnmarshalOptions
+    resolver      Resolver
+    int64AsNumber bool
+    int64Overflow Int64Overflow
+    coerceScalars bool
+    lenientNames  bool
+    aliases       *AliasTable
+    reportAlias   func(AliasUsage)
 }
 
 var _ Codec = (*protoJSONCodec)(nil)
 
+// Resolver 用于解析 Any 与扩展字段的类型, protoregistry.Types 即满足该接口
+type Resolver interface {
+    protoregistry.MessageTypeResolver
+    protoregistry.ExtensionTypeResolver
+}
+
+// ProtoJSONOption 配置 protojson 编解码器
+type ProtoJSONOption func(*protoJSONCodec)
+
+// WithUseProtoNames 输出时使用 proto 字段名(snake_case)而不是 json_name(lowerCamelCase)
+func WithUseProtoNames() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.UseProtoNames = true
+    }
+}
+
+// WithEmitUnpopulated 输出未赋值的字段, 未设置的消息字段输出为 null
+func WithEmitUnpopulated() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.EmitUnpopulated = true
+    }
+}
+
+// WithEmitDefaultValues 输出零值的标量/列表/map字段, 未设置的消息字段仍然省略
+func WithEmitDefaultValues() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.EmitDefaultValues = true
+    }
+}
+
+// WithUseEnumNumbers 枚举输出为数字而不是名称
+func WithUseEnumNumbers() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.UseEnumNumbers = true
+    }
+}
+
+// WithMultiline 多行格式化输出, 默认缩进为两个空格
+func WithMultiline() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.Multiline = true
+    }
+}
+
+// WithIndent 多行格式化输出并使用指定的缩进, indent 只能包含空格或制表符
+func WithIndent(indent string) ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.Multiline = true
+        c.marshal.Indent = indent
+    }
+}
+
+// WithAllowPartial 允许编解码缺少 required 字段的消息
+func WithAllowPartial() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.marshal.AllowPartial = true
+        c.unmarshal.AllowPartial = true
+    }
+}
+
+// WithResolver 使用自定义的类型解析器处理 Any 与扩展字段, 默认为 protoregistry.GlobalTypes
+func WithResolver(resolver Resolver) ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.resolver = resolver
+        c.marshal.Resolver = resolver
+        c.unmarshal.Resolver = resolver
+    }
+}
+
+// WithRejectUnknown 解码时遇到未知字段直接报错, 默认忽略未知字段
+func WithRejectUnknown() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.unmarshal.DiscardUnknown = false
+    }
+}
+
+func newProtoJSONCodec(opts ...ProtoJSONOption) *protoJSONCodec {
+    c := &protoJSONCodec{
+        name: codecNameJSON,
+        // Discard unknown fields so clients and servers aren't forced to always use
+        // exactly the same version of the schema.
+        unmarshal: protojson.UnmarshalOptions{DiscardUnknown: true},
+    }
+    for _, opt := range opts {
+        opt(c)
+    }
+    return c
+}
+
+// WithProtoJSON 使用默认配置的 protojson 编解码器
 func WithProtoJSON() connect.Option {
-    return connect.WithCodec(&protoJSONCodec{codecNameJSON})
+    return connect.WithCodec(newProtoJSONCodec())
 }
+
+// WithProtoJSONOptions 使用自定义配置的 protojson 编解码器
+// 示例:
+//
+//	kitrouter.Bootstrap(kitrouter.WithCodec(
+//	    kitcodec.WithProtoJSONOptions(kitcodec.WithUseProtoNames(), kitcodec.WithEmitUnpopulated()),
+//	))
+func WithProtoJSONOptions(opts ...ProtoJSONOption) connect.Option {
+    return connect.WithCodec(newProtoJSONCodec(opts...))
+}
+
 func (c *protoJSONCodec) Name() string { return c.name }
 
 func (c *protoJSONCodec) Marshal(message any) ([]byte, error) {
-    protoMessage, ok := message.(proto.Message)
-    if !ok {
-        return nil, errNotProto(message)
+    buf := getBuffer()
+    defer putBuffer(buf)
+    messageJSON, err := c.MarshalAppend((*buf)[:0], message)
+    if err != nil {
+        return nil, err
     }
-    return protojson.MarshalOptions{}.Marshal(protoMessage)
+    *buf = messageJSON
+    // 返回值归调用方所有, 复制一份刚好大小的切片, 缓冲区放回池中复用
+    return append(make([]byte, 0, len(messageJSON)), messageJSON...), nil
 }
 
 func (c *protoJSONCodec) MarshalAppend(dst []byte, message any) ([]byte, error) {
@@ -102,7 +215,17 @@ func (c *protoJSONCodec) MarshalAppend(dst []byte, message any) ([]byte, error)
     if !ok {
         return nil, errNotProto(message)
     }
-    return protojson.MarshalOptions{}.MarshalAppend(dst, protoMessage)
+    if !c.int64AsNumber {
+        return c.marshal.MarshalAppend(dst, protoMessage)
+    }
+    buf := getBuffer()
+    defer putBuffer(buf)
+    messageJSON, err := c.marshal.MarshalAppend((*buf)[:0], protoMessage)
+    if err != nil {
+        return nil, err
+    }
+    *buf = messageJSON
+    return appendInt64AsNumber(dst, messageJSON, protoMessage.ProtoReflect().Descriptor(), c.resolver, c.int64Overflow)
 }
 
 func (c *protoJSONCodec) Unmarshal(binary []byte, message any) error {
@@ -113,10 +236,14 @@ func (c *protoJSONCodec) Unmarshal(binary []byte, message any) error {
     if len(binary) == 0 {
         binary = []byte("{}")
     }
-    // Discard unknown fields so clients and servers aren't forced to always use
-    // exactly the same version of the schema.
-    options := protojson.UnmarshalOptions{DiscardUnknown: true}
-    err := options.Unmarshal(binary, protoMessage)
+    if c.lenientNames || c.coerceScalars {
+        normalized, err := c.lenientDecoder().normalize(protoMessage.ProtoReflect().Descriptor(), binary)
+        if err != nil {
+            return fmt.Errorf("unmarshal into %T: %w", message, err)
+        }
+        binary = normalized
+    }
+    err := c.unmarshal.Unmarshal(binary, protoMessage)
     if err != nil {
         return fmt.Errorf("unmarshal into %T: %w", message, err)
     }
@@ -129,15 +256,16 @@ func (c *protoJSONCodec) MarshalStable(message any) ([]byte, error) {
     // output inconsistent whitespace for some reason, therefore it is
     // suggested to use a formatter to ensure consistent formatting.
     // https://github.com/golang/protobuf/issues/1373
-    messageJSON, err := c.Marshal(message)
+    buf := getBuffer()
+    defer putBuffer(buf)
+    messageJSON, err := c.MarshalAppend((*buf)[:0], message)
     if err != nil {
         return nil, err
     }
-    compactedJSON := bytes.NewBuffer(messageJSON[:0])
-    if err = json.Compact(compactedJSON, messageJSON); err != nil {
-        return nil, err
-    }
-    return compactedJSON.Bytes(), nil
+    *buf = messageJSON
+    // protojson 的输出一定是合法 JSON, 原地去掉空白即可, 不需要 json.Compact 的二次校验
+    compacted := compactJSON(messageJSON)
+    return append(make([]byte, 0, len(compacted)), compacted...), nil
 }
 
 func (c *protoJSONCodec) IsBinary() bool {
@@ -0,0 +1,137 @@
+package kitcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// legacyProtoJSONCodec 改用缓冲池之前的实现, 仅用于基准对比
+type legacyProtoJSONCodec struct{}
+
+func (legacyProtoJSONCodec) Marshal(message any) ([]byte, error) {
+	return protojson.MarshalOptions{}.Marshal(message.(proto.Message))
+}
+
+func (c legacyProtoJSONCodec) MarshalStable(message any) ([]byte, error) {
+	messageJSON, err := c.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	compactedJSON := bytes.NewBuffer(messageJSON[:0])
+	if err = json.Compact(compactedJSON, messageJSON); err != nil {
+		return nil, err
+	}
+	return compactedJSON.Bytes(), nil
+}
+
+type benchCodec interface {
+	Marshal(any) ([]byte, error)
+	MarshalStable(any) ([]byte, error)
+}
+
+func benchMessages(b testing.TB) map[string]proto.Message {
+	small := mustUnmarshalText(b, "kitcodec.test.User", `user_id: 42 user_name: "alice" status: STATUS_ACTIVE active: true`)
+
+	var large strings.Builder
+	large.WriteString(`user_id: 42 user_name: "alice" `)
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&large, `profiles: { display_name: "profile-%d" tags: ["a", "b", "c"] } friend_ids: %d scores: %d `, i, i*1000, i)
+	}
+
+	var nested strings.Builder
+	nested.WriteString(`profile: `)
+	for i := 0; i < 64; i++ {
+		fmt.Fprintf(&nested, `{ display_name: "level-%d" child: `, i)
+	}
+	nested.WriteString(`{}`)
+	nested.WriteString(strings.Repeat(" }", 64))
+
+	return map[string]proto.Message{
+		"small":  small,
+		"large":  mustUnmarshalText(b, "kitcodec.test.User", large.String()),
+		"nested": mustUnmarshalText(b, "kitcodec.test.User", nested.String()),
+	}
+}
+
+func BenchmarkProtoJSONCodec(b *testing.B) {
+	codecs := []struct {
+		name  string
+		codec benchCodec
+	}{
+		{name: "legacy", codec: legacyProtoJSONCodec{}},
+		{name: "pooled", codec: newProtoJSONCodec()},
+	}
+	messages := benchMessages(b)
+	for _, size := range []string{"small", "large", "nested"} {
+		msg := messages[size]
+		for _, c := range codecs {
+			b.Run(size+"/Marshal/"+c.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := c.codec.Marshal(msg); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(size+"/MarshalStable/"+c.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := c.codec.MarshalStable(msg); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+		b.Run(size+"/MarshalAppend/pooled", func(b *testing.B) {
+			codec := newProtoJSONCodec()
+			b.ReportAllocs()
+			var dst []byte
+			for i := 0; i < b.N; i++ {
+				var err error
+				if dst, err = codec.MarshalAppend(dst[:0], msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: `{ "a" : 1 , "b" : [ 1, 2 ] }`, want: `{"a":1,"b":[1,2]}`},
+		{input: "{\n\t\"a b\": \"c \\\" d\"\r\n}", want: `{"a b":"c \" d"}`},
+		{input: `"\\" `, want: `"\\"`},
+		{input: `{}`, want: `{}`},
+	}
+	for _, tt := range tests {
+		if got := string(compactJSON([]byte(tt.input))); got != tt.want {
+			t.Errorf("compactJSON(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestProtoJSONCodec_MarshalStableMatchesLegacy(t *testing.T) {
+	codec := newProtoJSONCodec()
+	for name, msg := range benchMessages(t) {
+		want, err := legacyProtoJSONCodec{}.MarshalStable(msg)
+		if err != nil {
+			t.Fatalf("%s: legacy MarshalStable() error = %v", name, err)
+		}
+		got, err := codec.MarshalStable(msg)
+		if err != nil {
+			t.Fatalf("%s: MarshalStable() error = %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s: MarshalStable() = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package kitcodec
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestProtoJSONCodec_MarshalOptions(t *testing.T) {
+	const text = `user_id: 42 user_name: "alice" status: STATUS_ACTIVE`
+	tests := []struct {
+		name string
+		opts []ProtoJSONOption
+		want string
+	}{
+		{
+			name: "default",
+			want: `{"userId":"42","userName":"alice","status":"STATUS_ACTIVE"}`,
+		},
+		{
+			name: "use proto names",
+			opts: []ProtoJSONOption{WithUseProtoNames()},
+			want: `{"user_id":"42","user_name":"alice","status":"STATUS_ACTIVE"}`,
+		},
+		{
+			name: "use enum numbers",
+			opts: []ProtoJSONOption{WithUseEnumNumbers()},
+			want: `{"userId":"42","userName":"alice","status":1}`,
+		},
+		{
+			name: "emit default values",
+			opts: []ProtoJSONOption{WithEmitDefaultValues()},
+			want: `{"userId":"42","userName":"alice","status":"STATUS_ACTIVE","active":false,"scores":[],"balance":"0","friendIds":[],"counters":{},"profiles":[]}`,
+		},
+		{
+			name: "emit unpopulated",
+			opts: []ProtoJSONOption{WithEmitUnpopulated()},
+			want: `{"userId":"42","userName":"alice","status":"STATUS_ACTIVE","active":false,"scores":[],"profile":null,"createdAt":null,"balance":"0","friendIds":[],"counters":{},"profiles":[],"extra":null,"readMask":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := newProtoJSONCodec(tt.opts...)
+			got, err := codec.MarshalStable(mustUnmarshalText(t, "kitcodec.test.User", text))
+			if err != nil {
+				t.Fatalf("MarshalStable() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("MarshalStable() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoJSONCodec_Indent(t *testing.T) {
+	msg := mustUnmarshalText(t, "kitcodec.test.User", `user_name: "alice"`)
+
+	got, err := newProtoJSONCodec(WithMultiline()).Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(got), "\n  \"userName\":") {
+		t.Fatalf("Marshal() multiline = %q, want two-space indent", got)
+	}
+
+	got, err = newProtoJSONCodec(WithIndent("\t")).Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(got), "\n\t\"userName\":") {
+		t.Fatalf("Marshal() indent = %q, want tab indent", got)
+	}
+}
+
+func TestProtoJSONCodec_AllowPartial(t *testing.T) {
+	msg := newTestMessage(t, "kitcodec.test.Legacy")
+	msg.Set(msg.Descriptor().Fields().ByName("note"), protoreflect.ValueOfString("missing code"))
+
+	if _, err := newProtoJSONCodec().Marshal(msg); err == nil {
+		t.Fatalf("Marshal() without AllowPartial should fail on missing required field")
+	}
+	got, err := newProtoJSONCodec(WithAllowPartial()).MarshalStable(msg)
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	if want := `{"note":"missing code"}`; string(got) != want {
+		t.Fatalf("MarshalStable() = %s, want %s", got, want)
+	}
+
+	out := newTestMessage(t, "kitcodec.test.Legacy")
+	if err = newProtoJSONCodec().Unmarshal(got, out); err == nil {
+		t.Fatalf("Unmarshal() without AllowPartial should fail on missing required field")
+	}
+	if err = newProtoJSONCodec(WithAllowPartial()).Unmarshal(got, out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+}
+
+func TestProtoJSONCodec_RejectUnknown(t *testing.T) {
+	input := []byte(`{"userName":"alice","nickname":"a"}`)
+
+	out := newTestMessage(t, "kitcodec.test.User")
+	if err := newProtoJSONCodec().Unmarshal(input, out); err != nil {
+		t.Fatalf("Unmarshal() default should discard unknown fields, error = %v", err)
+	}
+	if got := out.Get(out.Descriptor().Fields().ByName("user_name")).String(); got != "alice" {
+		t.Fatalf("user_name = %q, want %q", got, "alice")
+	}
+
+	out = newTestMessage(t, "kitcodec.test.User")
+	if err := newProtoJSONCodec(WithRejectUnknown()).Unmarshal(input, out); err == nil {
+		t.Fatalf("Unmarshal() with RejectUnknown should fail on unknown field")
+	}
+}
+
+func TestProtoJSONCodec_Resolver(t *testing.T) {
+	profile := mustUnmarshalText(t, "kitcodec.test.Profile", `display_name: "bob"`)
+	extra, err := anypb.New(profile)
+	if err != nil {
+		t.Fatalf("anypb.New() error = %v", err)
+	}
+	msg := newTestMessage(t, "kitcodec.test.User")
+	msg.Set(msg.Descriptor().Fields().ByName("extra"), protoreflect.ValueOfMessage(extra.ProtoReflect()))
+
+	if _, err = newProtoJSONCodec().Marshal(msg); err == nil {
+		t.Fatalf("Marshal() with global resolver should fail on unregistered Any type")
+	}
+
+	types := new(protoregistry.Types)
+	if err = types.RegisterMessage(dynamicpb.NewMessageType(profile.Descriptor())); err != nil {
+		t.Fatalf("RegisterMessage() error = %v", err)
+	}
+	codec := newProtoJSONCodec(WithResolver(types))
+	got, err := codec.MarshalStable(msg)
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	want := `{"extra":{"@type":"type.googleapis.com/kitcodec.test.Profile","displayName":"bob"}}`
+	if string(got) != want {
+		t.Fatalf("MarshalStable() = %s, want %s", got, want)
+	}
+
+	out := newTestMessage(t, "kitcodec.test.User")
+	if err = codec.Unmarshal(got, out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	again, err := codec.MarshalStable(out)
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	if string(again) != want {
+		t.Fatalf("round trip = %s, want %s", again, want)
+	}
+}
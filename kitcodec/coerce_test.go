@@ -0,0 +1,147 @@
+package kitcodec
+
+import (
+	"testing"
+)
+
+func TestProtoJSONCodec_ScalarCoercion(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "bool variants",
+			input: `{"active":"on"}`,
+			want:  `{"active":true}`,
+		},
+		{
+			name:  "bool numeric string",
+			input: `{"active":"1"}`,
+			want:  `{"active":true}`,
+		},
+		{
+			name:  "bool false",
+			input: `{"active":"off","userName":"a"}`,
+			want:  `{"userName":"a"}`,
+		},
+		{
+			name:  "integers from strings",
+			input: `{"userId":" 42 ","balance":"7","scores":"3"}`,
+			want:  `{"userId":"42","scores":[3],"balance":"7"}`,
+		},
+		{
+			name:  "empty numeric string is unset",
+			input: `{"userId":"","userName":"a"}`,
+			want:  `{"userName":"a"}`,
+		},
+		{
+			name:  "enum by number",
+			input: `{"status":"2"}`,
+			want:  `{"status":"STATUS_BANNED"}`,
+		},
+		{
+			name:  "enum by name ignoring case",
+			input: `{"status":"status_active"}`,
+			want:  `{"status":"STATUS_ACTIVE"}`,
+		},
+		{
+			name:  "comma separated repeated",
+			input: `{"scores":"1, 2,3","friendIds":["4","5"],"profile":{"tags":"a,b"}}`,
+			want:  `{"scores":[1,2,3],"profile":{"tags":["a","b"]},"friendIds":["4","5"]}`,
+		},
+		{
+			name:  "timestamp unix seconds",
+			input: `{"createdAt":"1700000000"}`,
+			want:  `{"createdAt":"2023-11-14T22:13:20Z"}`,
+		},
+		{
+			name:  "timestamp rfc3339",
+			input: `{"createdAt":"2023-11-14T22:13:20Z"}`,
+			want:  `{"createdAt":"2023-11-14T22:13:20Z"}`,
+		},
+		{
+			name:  "map values",
+			input: `{"counters":{"a":"3"}}`,
+			want:  `{"counters":{"a":"3"}}`,
+		},
+		{
+			name:    "invalid bool still fails",
+			input:   `{"active":"maybe"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := newProtoJSONCodec(WithScalarCoercion())
+			out := newTestMessage(t, "kitcodec.test.User")
+			err := codec.Unmarshal([]byte(tt.input), out)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			got, err := codec.MarshalStable(out)
+			if err != nil {
+				t.Fatalf("MarshalStable() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("decoded = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoJSONCodec_ScalarCoercionOff(t *testing.T) {
+	out := newTestMessage(t, "kitcodec.test.User")
+	if err := newProtoJSONCodec().Unmarshal([]byte(`{"active":"1"}`), out); err == nil {
+		t.Fatalf("Unmarshal() without coercion should reject string bool")
+	}
+}
+
+func TestCoerceJSON(t *testing.T) {
+	got, err := CoerceJSON(testDescriptor(t, "kitcodec.test.User"), []byte(`{"active":"yes","scores":"1,2","unknown":"x"}`))
+	if err != nil {
+		t.Fatalf("CoerceJSON() error = %v", err)
+	}
+	if want := `{"active":true,"scores":[1,2],"unknown":"x"}`; string(got) != want {
+		t.Fatalf("CoerceJSON() = %s, want %s", got, want)
+	}
+}
+
+// TestProtoJSONCodec_ScalarCoercionSpecialFloats protojson 原生支持的 NaN、Infinity 等字符串不转换为数字
+func TestProtoJSONCodec_ScalarCoercionSpecialFloats(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"score":"Infinity"}`, `{"score":"Infinity"}`},
+		{`{"score":"-Infinity"}`, `{"score":"-Infinity"}`},
+		{`{"score":"NaN"}`, `{"score":"NaN"}`},
+		{`{"score":" 1.5e3 "}`, `{"score":1500}`},
+	}
+	codec := newProtoJSONCodec(WithScalarCoercion())
+	for _, tt := range tests {
+		out := newTestMessage(t, "kitcodec.test.Signup")
+		if err := codec.Unmarshal([]byte(tt.input), out); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.input, err)
+		}
+		got, err := codec.MarshalStable(out)
+		if err != nil {
+			t.Fatalf("MarshalStable() error = %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("decoded %s = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+	for _, input := range []string{`{"age":"+5"}`, `{"age":"0x10"}`, `{"score":"0x1p-2"}`} {
+		if _, err := CoerceJSON(testDescriptor(t, "kitcodec.test.Signup"), []byte(input)); err != nil {
+			t.Errorf("CoerceJSON(%s) error = %v, want value passed through", input, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package kitcodec
+
+import (
+    "compress/gzip"
+    "io"
+
+    "connectrpc.com/connect"
+    "github.com/andybalholm/brotli"
+    "github.com/klauspost/compress/zstd"
+)
+
+const (
+    // CompressionGzip gzip 压缩, Connect 默认已支持
+    CompressionGzip = "gzip"
+    // CompressionZstd zstd 压缩
+    CompressionZstd = "zstd"
+    // CompressionBrotli brotli 压缩
+    CompressionBrotli = "br"
+
+    // defaultCompressMinBytes 默认小于 1KB 的消息不压缩
+    defaultCompressMinBytes = 1024
+)
+
+// CompressionOption 配置压缩算法
+type CompressionOption func(*compressionConfig)
+
+type compressionConfig struct {
+    minBytes   int
+    algorithms []string
+}
+
+// WithCompressionMinBytes 设置压缩阈值, 小于 minBytes 的消息不压缩, 默认 1024
+func WithCompressionMinBytes(minBytes int) CompressionOption {
+    return func(c *compressionConfig) {
+        c.minBytes = minBytes
+    }
+}
+
+// WithCompressionAlgorithms 设置启用的压缩算法, 默认 gzip、br、zstd.
+// 对客户端而言越靠后的算法优先级越高.
+func WithCompressionAlgorithms(algorithms ...string) CompressionOption {
+    return func(c *compressionConfig) {
+        c.algorithms = algorithms
+    }
+}
+
+func newCompressionConfig(opts []CompressionOption) *compressionConfig {
+    c := &compressionConfig{
+        minBytes:   defaultCompressMinBytes,
+        algorithms: []string{CompressionGzip, CompressionBrotli, CompressionZstd},
+    }
+    for _, opt := range opts {
+        opt(c)
+    }
+    return c
+}
+
+// WithCompression 为 Connect handler 注册 gzip、zstd、brotli 压缩算法并设置压缩阈值,
+// 服务端根据 Accept-Encoding(unary) 或 Connect-Accept-Encoding(streaming) 协商响应的压缩算法
+// 示例:
+//
+//	kitrouter.Bootstrap(kitrouter.WithInterceptors(kitcodec.WithCompression()))
+func WithCompression(opts ...CompressionOption) connect.HandlerOption {
+    config := newCompressionConfig(opts)
+    options := make([]connect.HandlerOption, 0, len(config.algorithms)+1)
+    for _, name := range config.algorithms {
+        newDecompressor, newCompressor := compressors(name)
+        if newDecompressor == nil {
+            continue
+        }
+        options = append(options, connect.WithCompression(name, newDecompressor, newCompressor))
+    }
+    options = append(options, connect.WithCompressMinBytes(config.minBytes))
+    return connect.WithHandlerOptions(options...)
+}
+
+// WithClientCompression 让客户端接受 gzip、zstd、brotli 压缩的响应,
+// send 不为空时使用该算法压缩请求
+// 示例:
+//
+//	client := userv1connect.NewUserServiceClient(http.DefaultClient, url, kitcodec.WithClientCompression(kitcodec.CompressionZstd))
+func WithClientCompression(send string, opts ...CompressionOption) connect.ClientOption {
+    config := newCompressionConfig(opts)
+    options := make([]connect.ClientOption, 0, len(config.algorithms)+2)
+    for _, name := range config.algorithms {
+        newDecompressor, newCompressor := compressors(name)
+        if newDecompressor == nil {
+            continue
+        }
+        options = append(options, connect.WithAcceptCompression(name, newDecompressor, newCompressor))
+    }
+    options = append(options, connect.WithCompressMinBytes(config.minBytes))
+    if send != "" {
+        options = append(options, connect.WithSendCompression(send))
+    }
+    return connect.WithClientOptions(options...)
+}
+
+func compressors(name string) (func() connect.Decompressor, func() connect.Compressor) {
+    switch name {
+    case CompressionGzip:
+        return func() connect.Decompressor { return &gzip.Reader{} },
+            func() connect.Compressor { return gzip.NewWriter(io.Discard) }
+    case CompressionZstd:
+        return newZstdDecompressor, newZstdCompressor
+    case CompressionBrotli:
+        return func() connect.Decompressor { return &brotliDecompressor{Reader: brotli.NewReader(nil)} },
+            func() connect.Compressor { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }
+    }
+    return nil, nil
+}
+
+// zstdDecompressor 适配 connect.Decompressor, Connect 会池化复用,
+// 因此 Close 不释放解码器, 只在 Reset 时切换数据源
+type zstdDecompressor struct {
+    *zstd.Decoder
+}
+
+func newZstdDecompressor() connect.Decompressor {
+    // 并发度为 1 时同步解码, 不会启动后台 goroutine
+    decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+    if err != nil {
+        return &errDecompressor{err: err}
+    }
+    return &zstdDecompressor{Decoder: decoder}
+}
+
+func (d *zstdDecompressor) Close() error {
+    return d.Decoder.Reset(nil)
+}
+
+func newZstdCompressor() connect.Compressor {
+    encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+    if err != nil {
+        return &errCompressor{err: err}
+    }
+    return encoder
+}
+
+// brotliDecompressor 适配 connect.Decompressor
+type brotliDecompressor struct {
+    *brotli.Reader
+}
+
+func (d *brotliDecompressor) Close() error {
+    return nil
+}
+
+// errDecompressor 创建解码器失败时返回, 每次读取都报告该错误
+type errDecompressor struct {
+    err error
+}
+
+func (d *errDecompressor) Read([]byte) (int, error) { return 0, d.err }
+func (d *errDecompressor) Close() error             { return nil }
+func (d *errDecompressor) Reset(io.Reader) error    { return d.err }
+
+// errCompressor 创建编码器失败时返回, 每次写入都报告该错误
+type errCompressor struct {
+    err error
+}
+
+func (c *errCompressor) Write([]byte) (int, error) { return 0, c.err }
+func (c *errCompressor) Close() error              { return c.err }
+func (c *errCompressor) Reset(io.Writer)           {}
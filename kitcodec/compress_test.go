@@ -0,0 +1,193 @@
+package kitcodec
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	testUnaryProcedure  = "/kitcodec.test.EchoService/Echo"
+	testStreamProcedure = "/kitcodec.test.EchoService/EchoStream"
+)
+
+// headerRecorder 记录最近一次请求与响应的头
+type headerRecorder struct {
+	mu       sync.Mutex
+	request  http.Header
+	response http.Header
+}
+
+func (r *headerRecorder) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req)
+		r.mu.Lock()
+		r.request = req.Header.Clone()
+		r.response = w.Header().Clone()
+		r.mu.Unlock()
+	})
+}
+
+func (r *headerRecorder) get() (http.Header, http.Header) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.request, r.response
+}
+
+func newCompressionServer(t *testing.T, opts ...CompressionOption) (*httptest.Server, *headerRecorder) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(testUnaryProcedure, connect.NewUnaryHandler(
+		testUnaryProcedure,
+		func(_ context.Context, req *connect.Request[structpb.Struct]) (*connect.Response[structpb.Struct], error) {
+			return connect.NewResponse(req.Msg), nil
+		},
+		WithCompression(opts...),
+	))
+	mux.Handle(testStreamProcedure, connect.NewServerStreamHandler(
+		testStreamProcedure,
+		func(_ context.Context, req *connect.Request[structpb.Struct], stream *connect.ServerStream[structpb.Struct]) error {
+			for i := 0; i < 3; i++ {
+				if err := stream.Send(req.Msg); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		WithCompression(opts...),
+	))
+	recorder := &headerRecorder{}
+	server := httptest.NewServer(recorder.wrap(mux))
+	t.Cleanup(server.Close)
+	return server, recorder
+}
+
+func compressionPayload(t *testing.T, size int) *structpb.Struct {
+	t.Helper()
+	msg, err := structpb.NewStruct(map[string]any{"text": strings.Repeat("omnixkit ", size/9+1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestCompression_Unary(t *testing.T) {
+	server, recorder := newCompressionServer(t)
+	payload := compressionPayload(t, 4096)
+	for _, name := range []string{CompressionGzip, CompressionZstd, CompressionBrotli} {
+		t.Run(name, func(t *testing.T) {
+			client := connect.NewClient[structpb.Struct, structpb.Struct](
+				http.DefaultClient,
+				server.URL+testUnaryProcedure,
+				WithClientCompression(name, WithCompressionAlgorithms(name)),
+			)
+			res, err := client.CallUnary(context.Background(), connect.NewRequest(payload))
+			if err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if got := res.Msg.Fields["text"].GetStringValue(); got != payload.Fields["text"].GetStringValue() {
+				t.Fatalf("response text mismatch, len %d", len(got))
+			}
+			request, response := recorder.get()
+			// Connect 总会把内置的 gzip 作为兜底追加在末尾, 首选项应为配置的算法
+			if got := request.Get("Accept-Encoding"); !strings.HasPrefix(got, name) {
+				t.Errorf("Accept-Encoding = %q, want %q first", got, name)
+			}
+			if got := request.Get("Content-Encoding"); got != name {
+				t.Errorf("request Content-Encoding = %q, want %q", got, name)
+			}
+			if got := response.Get("Content-Encoding"); got != name {
+				t.Errorf("response Content-Encoding = %q, want %q", got, name)
+			}
+		})
+	}
+}
+
+func TestCompression_ServerStream(t *testing.T) {
+	server, recorder := newCompressionServer(t)
+	payload := compressionPayload(t, 4096)
+	for _, name := range []string{CompressionGzip, CompressionZstd, CompressionBrotli} {
+		t.Run(name, func(t *testing.T) {
+			client := connect.NewClient[structpb.Struct, structpb.Struct](
+				http.DefaultClient,
+				server.URL+testStreamProcedure,
+				WithClientCompression(name, WithCompressionAlgorithms(name)),
+			)
+			stream, err := client.CallServerStream(context.Background(), connect.NewRequest(payload))
+			if err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			count := 0
+			for stream.Receive() {
+				if got := stream.Msg().Fields["text"].GetStringValue(); got != payload.Fields["text"].GetStringValue() {
+					t.Fatalf("message %d text mismatch, len %d", count, len(got))
+				}
+				count++
+			}
+			if err = stream.Err(); err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+			if err = stream.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+			if count != 3 {
+				t.Fatalf("received %d messages, want 3", count)
+			}
+			request, response := recorder.get()
+			if got := request.Get("Connect-Accept-Encoding"); !strings.HasPrefix(got, name) {
+				t.Errorf("Connect-Accept-Encoding = %q, want %q first", got, name)
+			}
+			if got := request.Get("Connect-Content-Encoding"); got != name {
+				t.Errorf("request Connect-Content-Encoding = %q, want %q", got, name)
+			}
+			if got := response.Get("Connect-Content-Encoding"); got != name {
+				t.Errorf("response Connect-Content-Encoding = %q, want %q", got, name)
+			}
+		})
+	}
+}
+
+func TestCompression_Negotiation(t *testing.T) {
+	// 服务端只支持 gzip, 客户端同时接受 gzip 与 zstd
+	server, recorder := newCompressionServer(t, WithCompressionAlgorithms(CompressionGzip))
+	client := connect.NewClient[structpb.Struct, structpb.Struct](
+		http.DefaultClient,
+		server.URL+testUnaryProcedure,
+		WithClientCompression("", WithCompressionAlgorithms(CompressionGzip, CompressionZstd)),
+	)
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(compressionPayload(t, 4096))); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	request, response := recorder.get()
+	if got := request.Get("Accept-Encoding"); !strings.Contains(got, CompressionZstd) || !strings.Contains(got, CompressionGzip) {
+		t.Errorf("Accept-Encoding = %q, want gzip and zstd", got)
+	}
+	if got := response.Get("Content-Encoding"); got != CompressionGzip {
+		t.Errorf("response Content-Encoding = %q, want %q", got, CompressionGzip)
+	}
+}
+
+func TestCompression_MinBytes(t *testing.T) {
+	server, recorder := newCompressionServer(t, WithCompressionMinBytes(2048))
+	client := connect.NewClient[structpb.Struct, structpb.Struct](
+		http.DefaultClient,
+		server.URL+testUnaryProcedure,
+		WithClientCompression(CompressionZstd, WithCompressionMinBytes(2048)),
+	)
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(compressionPayload(t, 64))); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	request, response := recorder.get()
+	if got := request.Get("Content-Encoding"); got != "" {
+		t.Errorf("small request Content-Encoding = %q, want none", got)
+	}
+	if got := response.Get("Content-Encoding"); got != "" {
+		t.Errorf("small response Content-Encoding = %q, want none", got)
+	}
+}
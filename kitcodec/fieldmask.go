@@ -0,0 +1,307 @@
+package kitcodec
+
+import (
+    "context"
+    "fmt"
+    "reflect"
+    "strings"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+    // DefaultFieldMaskHeader 默认读取字段掩码的请求头
+    DefaultFieldMaskHeader = "X-Fields"
+    // DefaultFieldMaskParam 默认读取字段掩码的查询参数, 同时也是请求消息中默认的字段名
+    DefaultFieldMaskParam = "fields"
+)
+
+type fieldMaskContextKey struct{}
+
+// ContextWithFieldMask 将字段掩码写入上下文, 供 FieldMaskInterceptor 读取
+func ContextWithFieldMask(ctx context.Context, paths []string) context.Context {
+    return context.WithValue(ctx, fieldMaskContextKey{}, paths)
+}
+
+// GinMiddlewareFieldMask 读取查询参数(如 ?fields=id,profile.name)中的字段掩码写入请求上下文,
+// 需要放在 kitctx.GinMiddlewareAdapterMethodGet 之前
+func GinMiddlewareFieldMask(param string) gin.HandlerFunc {
+    if param == "" {
+        param = DefaultFieldMaskParam
+    }
+    return func(c *gin.Context) {
+        if value := c.Query(param); value != "" {
+            c.Request = c.Request.WithContext(ContextWithFieldMask(c.Request.Context(), splitPaths(value)))
+        }
+        c.Next()
+    }
+}
+
+// FieldMaskOption 配置 FieldMaskInterceptor
+type FieldMaskOption func(*FieldMaskInterceptor)
+
+// WithFieldMaskHeader 设置读取字段掩码的请求头, 默认 X-Fields
+func WithFieldMaskHeader(header string) FieldMaskOption {
+    return func(i *FieldMaskInterceptor) {
+        i.header = header
+    }
+}
+
+// WithFieldMaskRequestField 设置请求消息中字段掩码的字段名, 字段类型为 google.protobuf.FieldMask 或 string,
+// 默认 fields
+func WithFieldMaskRequestField(name protoreflect.Name) FieldMaskOption {
+    return func(i *FieldMaskInterceptor) {
+        i.requestField = name
+    }
+}
+
+// FieldMaskInterceptor 按请求中的字段掩码裁剪响应消息, 只保留请求的字段.
+// 字段掩码依次从请求消息字段、请求头、GinMiddlewareFieldMask 写入的上下文中读取,
+// 路径以逗号分隔, 嵌套字段用 . 连接, 字段名可以是 proto 字段名或 json_name;
+// repeated 与 map 字段的子路径作用于每个元素. 未知路径在调用 handler 之前返回 CodeInvalidArgument;
+// 需要 Spec.Schema 为 protoreflect.MethodDescriptor(protoc-gen-connect-go 生成的 handler 默认提供), 否则返回 CodeUnimplemented.
+// 裁剪的是响应消息的副本, handler 可以返回缓存或共享的消息.
+type FieldMaskInterceptor struct {
+    header       string
+    requestField protoreflect.Name
+}
+
+var _ connect.Interceptor = (*FieldMaskInterceptor)(nil)
+
+// NewFieldMaskInterceptor 创建字段掩码拦截器
+// 示例:
+//
+//	kitrouter.WithInterceptors(connect.WithInterceptors(kitcodec.NewFieldMaskInterceptor()))
+func NewFieldMaskInterceptor(opts ...FieldMaskOption) *FieldMaskInterceptor {
+    i := &FieldMaskInterceptor{
+        header:       DefaultFieldMaskHeader,
+        requestField: DefaultFieldMaskParam,
+    }
+    for _, opt := range opts {
+        opt(i)
+    }
+    return i
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *FieldMaskInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+        if req.Spec().IsClient {
+            return next(ctx, req)
+        }
+        paths := i.paths(ctx, req.Header(), req.Any())
+        if len(paths) == 0 {
+            return next(ctx, req)
+        }
+        if err := validateFieldMask(req.Spec(), paths); err != nil {
+            return nil, err
+        }
+        res, err := next(ctx, req)
+        if err != nil || res == nil {
+            return res, err
+        }
+        return maskResponse(res, paths)
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *FieldMaskInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *FieldMaskInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+        paths := i.paths(ctx, conn.RequestHeader(), nil)
+        if len(paths) == 0 {
+            return next(ctx, conn)
+        }
+        if err := validateFieldMask(conn.Spec(), paths); err != nil {
+            return err
+        }
+        return next(ctx, &fieldMaskStreamingHandlerConn{StreamingHandlerConn: conn, paths: paths})
+    }
+}
+
+type fieldMaskStreamingHandlerConn struct {
+    connect.StreamingHandlerConn
+
+    paths []string
+}
+
+func (s *fieldMaskStreamingHandlerConn) Send(msg any) error {
+    masked, err := applyFieldMask(msg, s.paths)
+    if err != nil {
+        return err
+    }
+    return s.StreamingHandlerConn.Send(masked)
+}
+
+// paths 按 请求消息字段 -> 请求头 -> 上下文 的顺序读取字段掩码
+func (i *FieldMaskInterceptor) paths(ctx context.Context, header interface{ Get(string) string }, msg any) []string {
+    if protoMessage, ok := msg.(proto.Message); ok && i.requestField != "" {
+        m := protoMessage.ProtoReflect()
+        if fd := m.Descriptor().Fields().ByName(i.requestField); fd != nil && m.Has(fd) {
+            switch {
+            case fd.Kind() == protoreflect.StringKind && !fd.IsList():
+                return splitPaths(m.Get(fd).String())
+            case fd.Message() != nil && fd.Message().FullName() == "google.protobuf.FieldMask":
+                list := m.Get(fd).Message().Get(fd.Message().Fields().ByName("paths")).List()
+                paths := make([]string, 0, list.Len())
+                for j := 0; j < list.Len(); j++ {
+                    paths = append(paths, list.Get(j).String())
+                }
+                return paths
+            }
+        }
+    }
+    if i.header != "" {
+        if value := header.Get(i.header); value != "" {
+            return splitPaths(value)
+        }
+    }
+    paths, _ := ctx.Value(fieldMaskContextKey{}).([]string)
+    return paths
+}
+
+// validateFieldMask 在调用 handler 之前校验路径, 避免 handler 产生副作用后请求才失败.
+// 无法从 Spec.Schema 得到响应类型时拒绝请求
+func validateFieldMask(spec connect.Spec, paths []string) error {
+    method, ok := spec.Schema.(protoreflect.MethodDescriptor)
+    if !ok {
+        return connect.NewError(connect.CodeUnimplemented, fmt.Errorf("field mask not supported by %s: method schema unavailable", spec.Procedure))
+    }
+    _, err := newFieldMaskTree(method.Output(), paths)
+    return err
+}
+
+// maskResponse 返回消息裁剪后的响应副本, handler 返回的响应与消息可能被缓存或共享, 不能原地修改
+func maskResponse(res connect.AnyResponse, paths []string) (connect.AnyResponse, error) {
+    masked, err := applyFieldMask(res.Any(), paths)
+    if err != nil {
+        return nil, err
+    }
+    // AnyResponse 只能由 connect.NewResponse 构造, 通过反射复制 *connect.Response[T] 后替换 Msg
+    original := reflect.ValueOf(res)
+    if original.Kind() != reflect.Pointer || original.Elem().Kind() != reflect.Struct {
+        return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("field mask: unsupported response type %T", res))
+    }
+    if msgField, ok := original.Elem().Type().FieldByName("Msg"); !ok || !reflect.TypeOf(masked).AssignableTo(msgField.Type) {
+        return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("field mask: unsupported response type %T", res))
+    }
+    copied := reflect.New(original.Elem().Type())
+    copied.Elem().Set(original.Elem())
+    copied.Elem().FieldByName("Msg").Set(reflect.ValueOf(masked))
+    return copied.Interface().(connect.AnyResponse), nil
+}
+
+// applyFieldMask 返回 msg 的副本, 只保留 paths 中的字段; msg 本身不会被修改
+func applyFieldMask(msg any, paths []string) (proto.Message, error) {
+    protoMessage, ok := msg.(proto.Message)
+    if !ok {
+        return nil, errNotProto(msg)
+    }
+    tree, err := newFieldMaskTree(protoMessage.ProtoReflect().Descriptor(), paths)
+    if err != nil {
+        return nil, err
+    }
+    masked := proto.Clone(protoMessage)
+    tree.prune(masked.ProtoReflect())
+    return masked, nil
+}
+
+// fieldMaskTree 以字段号为键的路径树, children 为 nil 表示保留整个字段
+type fieldMaskTree map[protoreflect.FieldNumber]fieldMaskTree
+
+func newFieldMaskTree(desc protoreflect.MessageDescriptor, paths []string) (fieldMaskTree, error) {
+    tree := make(fieldMaskTree)
+    for _, path := range paths {
+        if err := tree.add(desc, path); err != nil {
+            return nil, err
+        }
+    }
+    return tree, nil
+}
+
+func (t fieldMaskTree) add(desc protoreflect.MessageDescriptor, path string) error {
+    node := t
+    segments := strings.Split(path, ".")
+    for i, segment := range segments {
+        if desc == nil {
+            return errInvalidFieldMaskPath(path)
+        }
+        fd := desc.Fields().ByName(protoreflect.Name(segment))
+        if fd == nil {
+            fd = desc.Fields().ByJSONName(segment)
+        }
+        if fd == nil {
+            return errInvalidFieldMaskPath(path)
+        }
+        child, exists := node[fd.Number()]
+        if exists && child == nil {
+            // 父路径已经要求保留整个字段
+            return nil
+        }
+        if i == len(segments)-1 {
+            node[fd.Number()] = nil
+            return nil
+        }
+        if child == nil {
+            child = make(fieldMaskTree)
+            node[fd.Number()] = child
+        }
+        node = child
+        desc = fieldMessage(fd)
+    }
+    return nil
+}
+
+// fieldMessage 返回可以继续向下裁剪的消息类型, map 字段取 value 的类型
+func fieldMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+    if fd.IsMap() {
+        return fd.MapValue().Message()
+    }
+    return fd.Message()
+}
+
+func (t fieldMaskTree) prune(m protoreflect.Message) {
+    m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+        child, ok := t[fd.Number()]
+        switch {
+        case !ok:
+            m.Clear(fd)
+        case child == nil:
+        case fd.IsList():
+            list := v.List()
+            for i := 0; i < list.Len(); i++ {
+                child.prune(list.Get(i).Message())
+            }
+        case fd.IsMap():
+            v.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+                child.prune(value.Message())
+                return true
+            })
+        default:
+            child.prune(v.Message())
+        }
+        return true
+    })
+}
+
+func errInvalidFieldMaskPath(path string) error {
+    return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid field mask path %q", path))
+}
+
+func splitPaths(value string) []string {
+    parts := strings.Split(value, ",")
+    paths := make([]string, 0, len(parts))
+    for _, part := range parts {
+        if part = strings.TrimSpace(part); part != "" {
+            paths = append(paths, part)
+        }
+    }
+    return paths
+}
@@ -0,0 +1,312 @@
+package kitcodec
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const fieldMaskUserText = `
+user_id: 1
+user_name: "alice"
+status: STATUS_ACTIVE
+profile: { display_name: "a" tags: ["x"] child: { display_name: "b" tags: ["y"] } }
+profiles: [{ display_name: "p1" tags: ["t1"] }, { display_name: "p2" tags: ["t2"] }]
+counters: { key: "k" value: 3 }
+`
+
+func TestApplyFieldMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "top level fields",
+			paths: []string{"user_id", "userName"},
+			want:  `{"userId":"1","userName":"alice"}`,
+		},
+		{
+			name:  "nested path",
+			paths: []string{"profile.child.display_name"},
+			want:  `{"profile":{"child":{"displayName":"b"}}}`,
+		},
+		{
+			name:  "repeated message sub path",
+			paths: []string{"profiles.displayName"},
+			want:  `{"profiles":[{"displayName":"p1"},{"displayName":"p2"}]}`,
+		},
+		{
+			name:  "parent path wins over child path",
+			paths: []string{"profile.display_name", "profile"},
+			want:  `{"profile":{"displayName":"a","tags":["x"],"child":{"displayName":"b","tags":["y"]}}}`,
+		},
+		{
+			name:  "map field",
+			paths: []string{"counters"},
+			want:  `{"counters":{"k":"3"}}`,
+		},
+		{
+			name:    "unknown field",
+			paths:   []string{"password"},
+			wantErr: true,
+		},
+		{
+			name:    "path into scalar",
+			paths:   []string{"user_name.length"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)
+			masked, err := applyFieldMask(msg, tt.paths)
+			if tt.wantErr {
+				if connect.CodeOf(err) != connect.CodeInvalidArgument {
+					t.Fatalf("applyFieldMask() error = %v, want CodeInvalidArgument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyFieldMask() error = %v", err)
+			}
+			got, err := newProtoJSONCodec().MarshalStable(masked)
+			if err != nil {
+				t.Fatalf("MarshalStable() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("masked = %s, want %s", got, tt.want)
+			}
+			if !proto.Equal(msg, mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)) {
+				t.Fatal("applyFieldMask() modified the original message")
+			}
+		})
+	}
+}
+
+func TestFieldMaskInterceptor_Sources(t *testing.T) {
+	handler := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)), nil
+	}
+	// 请求消息使用 Profile.display_name 作为字符串类型的字段掩码字段
+	newRequest := func(mask string) *connect.Request[proto.Message] {
+		msg := newTestMessage(t, "kitcodec.test.Profile")
+		if mask != "" {
+			msg.Set(msg.Descriptor().Fields().ByName("display_name"), protoreflect.ValueOfString(mask))
+		}
+		var m proto.Message = msg
+		return connect.NewRequest(&m)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header string
+		field  string
+		want   string
+	}{
+		{
+			name: "no mask",
+			ctx:  context.Background(),
+			want: "",
+		},
+		{
+			name:   "header",
+			ctx:    context.Background(),
+			header: "user_id",
+			want:   `{"userId":"1"}`,
+		},
+		{
+			name: "context",
+			ctx:  ContextWithFieldMask(context.Background(), []string{"status"}),
+			want: `{"status":"STATUS_ACTIVE"}`,
+		},
+		{
+			name:   "request field wins",
+			ctx:    ContextWithFieldMask(context.Background(), []string{"status"}),
+			header: "user_id",
+			field:  "userName",
+			want:   `{"userName":"alice"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := NewFieldMaskInterceptor(WithFieldMaskRequestField("display_name"))
+			req := newRequest(tt.field)
+			if tt.header != "" {
+				req.Header().Set(DefaultFieldMaskHeader, tt.header)
+			}
+			res, err := interceptor.WrapUnary(handler)(tt.ctx, newAnyRequest(t, req))
+			if err != nil {
+				t.Fatalf("WrapUnary() error = %v", err)
+			}
+			got, err := newProtoJSONCodec().MarshalStable(res.Any())
+			if err != nil {
+				t.Fatalf("MarshalStable() error = %v", err)
+			}
+			if tt.want != "" && string(got) != tt.want {
+				t.Fatalf("response = %s, want %s", got, tt.want)
+			}
+			if tt.want == "" && len(got) < 100 {
+				t.Fatalf("response = %s, want unmasked message", got)
+			}
+		})
+	}
+}
+
+func TestFieldMaskInterceptor_FieldMaskMessage(t *testing.T) {
+	msg := mustUnmarshalText(t, "kitcodec.test.User", `read_mask: { paths: ["user_name", "profile.display_name"] }`)
+	var m proto.Message = msg
+	interceptor := NewFieldMaskInterceptor(WithFieldMaskRequestField("read_mask"))
+	res, err := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)), nil
+	})(context.Background(), newAnyRequest(t, connect.NewRequest(&m)))
+	if err != nil {
+		t.Fatalf("WrapUnary() error = %v", err)
+	}
+	got, err := newProtoJSONCodec().MarshalStable(res.Any())
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	if want := `{"userName":"alice","profile":{"displayName":"a"}}`; string(got) != want {
+		t.Fatalf("response = %s, want %s", got, want)
+	}
+}
+
+func TestFieldMaskInterceptor_InvalidPath(t *testing.T) {
+	interceptor := NewFieldMaskInterceptor()
+	var m proto.Message = newTestMessage(t, "kitcodec.test.User")
+	req := connect.NewRequest(&m)
+	req.Header().Set(DefaultFieldMaskHeader, "nope")
+	called := false
+	_, err := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		called = true
+		return connect.NewResponse(mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)), nil
+	})(context.Background(), newAnyRequest(t, req))
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeInvalidArgument {
+		t.Fatalf("WrapUnary() error = %v, want CodeInvalidArgument", err)
+	}
+	if called {
+		t.Fatal("handler called with invalid field mask")
+	}
+}
+
+// TestFieldMaskInterceptor_NoSchema 无法得到响应类型时在调用 handler 之前拒绝请求
+func TestFieldMaskInterceptor_NoSchema(t *testing.T) {
+	interceptor := NewFieldMaskInterceptor()
+	req := connect.NewRequest(&emptypb.Empty{})
+	req.Header().Set(DefaultFieldMaskHeader, "user_id")
+	called := false
+	_, err := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		called = true
+		return connect.NewResponse(mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)), nil
+	})(context.Background(), req)
+	if connect.CodeOf(err) != connect.CodeUnimplemented || called {
+		t.Fatalf("WrapUnary() error = %v, called = %v, want CodeUnimplemented before handler", err, called)
+	}
+}
+
+// TestFieldMaskInterceptor_SharedResponse handler 返回的共享消息不会被裁剪
+func TestFieldMaskInterceptor_SharedResponse(t *testing.T) {
+	shared := mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)
+	sharedResponse := connect.NewResponse(shared)
+	interceptor := NewFieldMaskInterceptor()
+	call := interceptor.WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return sharedResponse, nil
+	})
+	var m proto.Message = newTestMessage(t, "kitcodec.test.User")
+	req := connect.NewRequest(&m)
+	req.Header().Set(DefaultFieldMaskHeader, "user_id")
+	res, err := call(context.Background(), newAnyRequest(t, req))
+	if err != nil {
+		t.Fatalf("WrapUnary() error = %v", err)
+	}
+	got, err := newProtoJSONCodec().MarshalStable(res.Any())
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	if want := `{"userId":"1"}`; string(got) != want {
+		t.Fatalf("response = %s, want %s", got, want)
+	}
+	if sharedResponse.Msg != shared || !proto.Equal(shared, mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)) {
+		t.Fatal("shared response was modified")
+	}
+}
+
+// sendRecorder 记录 Send 的消息
+type sendRecorder struct {
+	connect.StreamingHandlerConn
+	sent []any
+}
+
+func (r *sendRecorder) Send(msg any) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func TestFieldMaskStreamingHandlerConn_Send(t *testing.T) {
+	shared := mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)
+	recorder := &sendRecorder{}
+	conn := &fieldMaskStreamingHandlerConn{StreamingHandlerConn: recorder, paths: []string{"user_name"}}
+	if err := conn.Send(shared); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	got, err := newProtoJSONCodec().MarshalStable(recorder.sent[0])
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	if want := `{"userName":"alice"}`; string(got) != want {
+		t.Fatalf("sent = %s, want %s", got, want)
+	}
+	if !proto.Equal(shared, mustUnmarshalText(t, "kitcodec.test.User", fieldMaskUserText)) {
+		t.Fatal("Send() modified the original message")
+	}
+}
+
+func TestGinMiddlewareFieldMask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got []string
+	r := gin.New()
+	r.Use(GinMiddlewareFieldMask(""))
+	r.GET("/x", func(c *gin.Context) {
+		got, _ = c.Request.Context().Value(fieldMaskContextKey{}).([]string)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x?fields=user_id,+profile.display_name", nil))
+	if len(got) != 2 || got[0] != "user_id" || got[1] != "profile.display_name" {
+		t.Fatalf("paths = %v, want [user_id profile.display_name]", got)
+	}
+}
+
+// anyRequest 让 Any() 返回动态消息本身而不是 *proto.Message, 并提供 GetUser 的 Spec
+type anyRequest struct {
+	*connect.Request[proto.Message]
+	spec connect.Spec
+}
+
+func newAnyRequest(t *testing.T, req *connect.Request[proto.Message]) *anyRequest {
+	t.Helper()
+	desc, err := testFiles(t).FindDescriptorByName("kitcodec.test.UserService.GetUser")
+	if err != nil {
+		t.Fatalf("find method: %v", err)
+	}
+	return &anyRequest{Request: req, spec: connect.Spec{
+		Procedure:  "/kitcodec.test.UserService/GetUser",
+		Schema:     desc,
+		StreamType: connect.StreamTypeUnary,
+	}}
+}
+
+func (r *anyRequest) Any() any { return *r.Msg }
+
+func (r *anyRequest) Spec() connect.Spec { return r.spec }
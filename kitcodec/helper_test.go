@@ -0,0 +1,156 @@
+package kitcodec
+
+import (
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testProto3File 测试用的 proto3 消息定义
+const testProto3File = `
+name: "kitcodec/test.proto"
+package: "kitcodec.test"
+syntax: "proto3"
+dependency: "google/protobuf/timestamp.proto"
+dependency: "google/protobuf/any.proto"
+dependency: "google/protobuf/field_mask.proto"
+dependency: "google/protobuf/wrappers.proto"
+dependency: "buf/validate/validate.proto"
+enum_type: {
+  name: "Status"
+  value: { name: "STATUS_UNSPECIFIED" number: 0 }
+  value: { name: "STATUS_ACTIVE" number: 1 }
+  value: { name: "STATUS_BANNED" number: 2 }
+}
+message_type: {
+  name: "Profile"
+  field: { name: "display_name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "displayName" }
+  field: { name: "tags" number: 2 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" }
+  field: { name: "child" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".kitcodec.test.Profile" json_name: "child" }
+}
+message_type: {
+  name: "User"
+  field: { name: "user_id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "userId" }
+  field: { name: "user_name" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "userName" }
+  field: { name: "status" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".kitcodec.test.Status" json_name: "status" }
+  field: { name: "active" number: 4 label: LABEL_OPTIONAL type: TYPE_BOOL json_name: "active" }
+  field: { name: "scores" number: 5 label: LABEL_REPEATED type: TYPE_INT32 json_name: "scores" }
+  field: { name: "profile" number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".kitcodec.test.Profile" json_name: "profile" }
+  field: { name: "created_at" number: 7 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "createdAt" }
+  field: { name: "balance" number: 8 label: LABEL_OPTIONAL type: TYPE_UINT64 json_name: "balance" }
+  field: { name: "friend_ids" number: 9 label: LABEL_REPEATED type: TYPE_INT64 json_name: "friendIds" }
+  field: { name: "counters" number: 10 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".kitcodec.test.User.CountersEntry" json_name: "counters" }
+  field: { name: "profiles" number: 11 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".kitcodec.test.Profile" json_name: "profiles" }
+  field: { name: "extra" number: 12 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Any" json_name: "extra" }
+  field: { name: "read_mask" number: 13 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.FieldMask" json_name: "readMask" }
+  nested_type: {
+    name: "CountersEntry"
+    field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "key" }
+    field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "value" }
+    options: { map_entry: true }
+  }
+}
+message_type: {
+  name: "Signup"
+  field: { name: "email" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "email" options: { [buf.validate.field]: { required: true string: { email: true } } } }
+  field: { name: "password" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "password" options: { [buf.validate.field]: { string: { min_len: 8 max_len: 64 pattern: "^[A-Za-z0-9]+$" } } } }
+  field: { name: "age" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "age" options: { [buf.validate.field]: { int32: { gte: 18 lt: 150 } } } }
+  field: { name: "status" number: 4 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".kitcodec.test.Status" json_name: "status" options: { [buf.validate.field]: { enum: { not_in: 0 } } } }
+  field: { name: "tags" number: 5 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" options: { [buf.validate.field]: { repeated: { min_items: 1 max_items: 5 unique: true items: { string: { min_len: 2 } } } } } }
+  field: { name: "plan" number: 6 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "plan" options: { [buf.validate.field]: { string: { in: "free" in: "pro" } } } }
+  field: { name: "score" number: 7 label: LABEL_OPTIONAL type: TYPE_DOUBLE json_name: "score" options: { [buf.validate.field]: { double: { gt: 10 lt: 5 } } } }
+  field: { name: "limit" number: 8 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Int32Value" json_name: "limit" options: { [buf.validate.field]: { int32: { lte: 100 } } } }
+  field: { name: "note" number: 9 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "note" options: { [buf.validate.field]: { ignore: IGNORE_ALWAYS string: { min_len: 1 } } } }
+  field: { name: "quota" number: 10 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "quota" options: { [buf.validate.field]: { int64: { gt: 0 lte: 1000 } } } }
+  field: { name: "tier" number: 11 label: LABEL_OPTIONAL type: TYPE_UINT64 json_name: "tier" options: { [buf.validate.field]: { uint64: { in: [1, 2] } } } }
+}
+service: {
+  name: "UserService"
+  method: { name: "GetUser" input_type: ".kitcodec.test.User" output_type: ".kitcodec.test.User" options: { idempotency_level: NO_SIDE_EFFECTS } }
+  method: { name: "UpdateUser" input_type: ".kitcodec.test.User" output_type: ".kitcodec.test.Profile" }
+  method: { name: "WatchUser" input_type: ".kitcodec.test.User" output_type: ".kitcodec.test.User" server_streaming: true }
+}
+`
+
+// testProto2File 测试用的 proto2 消息定义, 用于 required 字段
+const testProto2File = `
+name: "kitcodec/legacy.proto"
+package: "kitcodec.test"
+syntax: "proto2"
+message_type: {
+  name: "Legacy"
+  field: { name: "code" number: 1 label: LABEL_REQUIRED type: TYPE_STRING json_name: "code" }
+  field: { name: "note" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "note" }
+}
+`
+
+var (
+	testFilesOnce sync.Once
+	testFilesReg  *protoregistry.Files
+	testFilesErr  error
+)
+
+// testFiles 构建测试用的文件描述(只构建一次, 保证同名消息的描述相同), 不注册到全局注册表
+func testFiles(t testing.TB) *protoregistry.Files {
+	t.Helper()
+	testFilesOnce.Do(func() {
+		testFilesReg = new(protoregistry.Files)
+		for _, text := range []string{testProto3File, testProto2File} {
+			fdp := new(descriptorpb.FileDescriptorProto)
+			if testFilesErr = prototext.Unmarshal([]byte(text), fdp); testFilesErr != nil {
+				return
+			}
+			fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+			if err != nil {
+				testFilesErr = err
+				return
+			}
+			if testFilesErr = testFilesReg.RegisterFile(fd); testFilesErr != nil {
+				return
+			}
+		}
+	})
+	if testFilesErr != nil {
+		t.Fatalf("build test descriptors: %v", testFilesErr)
+	}
+	return testFilesReg
+}
+
+// testDescriptor 按全名获取测试消息描述
+func testDescriptor(t testing.TB, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	t.Helper()
+	desc, err := testFiles(t).FindDescriptorByName(name)
+	if err != nil {
+		t.Fatalf("find %s: %v", name, err)
+	}
+	return desc.(protoreflect.MessageDescriptor)
+}
+
+// newTestMessage 创建测试消息的动态实例
+func newTestMessage(t testing.TB, name protoreflect.FullName) *dynamicpb.Message {
+	t.Helper()
+	return dynamicpb.NewMessage(testDescriptor(t, name))
+}
+
+// mustUnmarshalText 使用 prototext 填充测试消息
+func mustUnmarshalText(t testing.TB, name protoreflect.FullName, text string) *dynamicpb.Message {
+	t.Helper()
+	msg := newTestMessage(t, name)
+	if err := prototext.Unmarshal([]byte(text), msg); err != nil {
+		t.Fatalf("prototext unmarshal %s: %v", name, err)
+	}
+	return msg
+}
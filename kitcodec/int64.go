@@ -0,0 +1,385 @@
+package kitcodec
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// maxSafeInteger JavaScript Number.MAX_SAFE_INTEGER, 即 2^53-1
+const maxSafeInteger = 1<<53 - 1
+
+// Int64Overflow 64 位整数超出 JavaScript 安全整数范围时的处理策略
+type Int64Overflow int
+
+const (
+    // Int64OverflowString 超出范围时保留 protojson 默认的字符串格式
+    Int64OverflowString Int64Overflow = iota
+    // Int64OverflowError 超出范围时编码失败
+    Int64OverflowError
+    // Int64OverflowClamp 超出范围时截断为 ±(2^53-1)
+    Int64OverflowClamp
+)
+
+// WithInt64AsNumber 将 int64/uint64/fixed64 等 64 位整数字段输出为 JSON 数字而不是字符串,
+// 超出 ±(2^53-1) 的值按 overflow 策略处理.
+// 解码时 protojson 本身同时接受数字和字符串, 无需额外配置.
+func WithInt64AsNumber(overflow Int64Overflow) ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.int64AsNumber = true
+        c.int64Overflow = overflow
+    }
+}
+
+// appendInt64AsNumber 按消息描述改写 protojson 的输出, 把带引号的 64 位整数改为数字后追加到 dst
+func appendInt64AsNumber(dst, src []byte, desc protoreflect.MessageDescriptor, resolver Resolver, overflow Int64Overflow) ([]byte, error) {
+    if resolver == nil {
+        resolver = protoregistry.GlobalTypes
+    }
+    r := &int64Rewriter{src: src, dst: dst, resolver: resolver, overflow: overflow}
+    r.space()
+    if err := r.messageValue(desc); err != nil {
+        return nil, err
+    }
+    r.dst = append(r.dst, r.src[r.pos:]...)
+    return r.dst, nil
+}
+
+// int64Rewriter 逐字节扫描 protojson 输出, 除 64 位整数外原样复制, 保留原有的格式与缩进
+type int64Rewriter struct {
+    src      []byte
+    pos      int
+    dst      []byte
+    resolver Resolver
+    overflow Int64Overflow
+}
+
+func (r *int64Rewriter) space() {
+    start := r.pos
+    for r.pos < len(r.src) {
+        switch r.src[r.pos] {
+        case ' ', '\t', '\n', '\r':
+            r.pos++
+            continue
+        }
+        break
+    }
+    r.dst = append(r.dst, r.src[start:r.pos]...)
+}
+
+func (r *int64Rewriter) peek() byte {
+    if r.pos >= len(r.src) {
+        return 0
+    }
+    return r.src[r.pos]
+}
+
+// copyByte 复制一个结构字符, 如 { } [ ] : ,
+func (r *int64Rewriter) copyByte() {
+    r.dst = append(r.dst, r.src[r.pos])
+    r.pos++
+}
+
+// next 返回当前位置的完整 JSON 值并前移
+func (r *int64Rewriter) next() []byte {
+    start := r.pos
+    switch r.peek() {
+    case '"':
+        r.pos = stringEnd(r.src, r.pos)
+    case '{', '[':
+        depth := 0
+        for r.pos < len(r.src) {
+            switch r.src[r.pos] {
+            case '"':
+                r.pos = stringEnd(r.src, r.pos)
+                continue
+            case '{', '[':
+                depth++
+            case '}', ']':
+                depth--
+            }
+            r.pos++
+            if depth == 0 {
+                break
+            }
+        }
+    default:
+        for r.pos < len(r.src) {
+            switch r.src[r.pos] {
+            case ',', '}', ']', ' ', '\t', '\n', '\r':
+                return r.src[start:r.pos]
+            }
+            r.pos++
+        }
+    }
+    return r.src[start:r.pos]
+}
+
+func (r *int64Rewriter) copyValue() {
+    r.dst = append(r.dst, r.next()...)
+}
+
+// object 遍历对象的每个键, 由 value 处理对应的值
+func (r *int64Rewriter) object(value func(key string) error) error {
+    if r.peek() != '{' {
+        r.copyValue()
+        return nil
+    }
+    r.copyByte()
+    for {
+        r.space()
+        if r.peek() == '}' {
+            r.copyByte()
+            return nil
+        }
+        raw := r.next()
+        r.dst = append(r.dst, raw...)
+        key, err := unquote(raw)
+        if err != nil {
+            return err
+        }
+        r.space()
+        r.copyByte() // :
+        r.space()
+        if err = value(key); err != nil {
+            return err
+        }
+        r.space()
+        if r.peek() == ',' {
+            r.copyByte()
+        }
+    }
+}
+
+func (r *int64Rewriter) message(desc protoreflect.MessageDescriptor) error {
+    return r.object(func(key string) error {
+        fd := r.lookupField(desc, key)
+        if fd == nil {
+            r.copyValue()
+            return nil
+        }
+        return r.field(fd)
+    })
+}
+
+func (r *int64Rewriter) lookupField(desc protoreflect.MessageDescriptor, key string) protoreflect.FieldDescriptor {
+    if len(key) > 2 && key[0] == '[' && key[len(key)-1] == ']' {
+        xt, err := r.resolver.FindExtensionByName(protoreflect.FullName(key[1 : len(key)-1]))
+        if err != nil {
+            return nil
+        }
+        return xt.TypeDescriptor()
+    }
+    if fd := desc.Fields().ByJSONName(key); fd != nil {
+        return fd
+    }
+    return desc.Fields().ByName(protoreflect.Name(key))
+}
+
+func (r *int64Rewriter) field(fd protoreflect.FieldDescriptor) error {
+    switch {
+    case r.peek() == 'n':
+        r.copyValue()
+        return nil
+    case fd.IsMap():
+        return r.object(func(string) error {
+            return r.single(fd.MapValue())
+        })
+    case fd.IsList():
+        if r.peek() != '[' {
+            r.copyValue()
+            return nil
+        }
+        r.copyByte()
+        for {
+            r.space()
+            if r.peek() == ']' {
+                r.copyByte()
+                return nil
+            }
+            if err := r.single(fd); err != nil {
+                return err
+            }
+            r.space()
+            if r.peek() == ',' {
+                r.copyByte()
+            }
+        }
+    default:
+        return r.single(fd)
+    }
+}
+
+func (r *int64Rewriter) single(fd protoreflect.FieldDescriptor) error {
+    switch fd.Kind() {
+    case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+        return r.integer(string(fd.FullName()), false)
+    case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+        return r.integer(string(fd.FullName()), true)
+    case protoreflect.MessageKind, protoreflect.GroupKind:
+        return r.messageValue(fd.Message())
+    default:
+        r.copyValue()
+        return nil
+    }
+}
+
+func (r *int64Rewriter) messageValue(desc protoreflect.MessageDescriptor) error {
+    switch desc.FullName() {
+    case "google.protobuf.Int64Value":
+        return r.integer(string(desc.FullName()), false)
+    case "google.protobuf.UInt64Value":
+        return r.integer(string(desc.FullName()), true)
+    case "google.protobuf.Any":
+        return r.any()
+    }
+    if isWellKnownJSON(desc.FullName()) {
+        r.copyValue()
+        return nil
+    }
+    return r.message(desc)
+}
+
+// any 处理 Any, 根据 @type 解析出实际的消息类型后继续改写
+func (r *int64Rewriter) any() error {
+    var desc protoreflect.MessageDescriptor
+    return r.object(func(key string) error {
+        if key == "@type" {
+            raw := r.next()
+            r.dst = append(r.dst, raw...)
+            url, err := unquote(raw)
+            if err != nil {
+                return err
+            }
+            if mt, err := r.resolver.FindMessageByURL(url); err == nil {
+                desc = mt.Descriptor()
+            }
+            return nil
+        }
+        if desc == nil {
+            r.copyValue()
+            return nil
+        }
+        if isWellKnownJSON(desc.FullName()) {
+            if key != "value" {
+                r.copyValue()
+                return nil
+            }
+            return r.messageValue(desc)
+        }
+        fd := r.lookupField(desc, key)
+        if fd == nil {
+            r.copyValue()
+            return nil
+        }
+        return r.field(fd)
+    })
+}
+
+// integer 将字符串形式的 64 位整数改写为数字
+func (r *int64Rewriter) integer(name string, unsigned bool) error {
+    raw := r.next()
+    if len(raw) < 2 || raw[0] != '"' {
+        r.dst = append(r.dst, raw...)
+        return nil
+    }
+    digits := raw[1 : len(raw)-1]
+    if unsigned {
+        v, err := strconv.ParseUint(string(digits), 10, 64)
+        if err != nil {
+            return fmt.Errorf("parse %s: %w", name, err)
+        }
+        if v <= maxSafeInteger {
+            r.dst = strconv.AppendUint(r.dst, v, 10)
+            return nil
+        }
+        return r.outOfRange(name, raw, v > maxSafeInteger)
+    }
+    v, err := strconv.ParseInt(string(digits), 10, 64)
+    if err != nil {
+        return fmt.Errorf("parse %s: %w", name, err)
+    }
+    if v >= -maxSafeInteger && v <= maxSafeInteger {
+        r.dst = strconv.AppendInt(r.dst, v, 10)
+        return nil
+    }
+    return r.outOfRange(name, raw, v > 0)
+}
+
+func (r *int64Rewriter) outOfRange(name string, raw []byte, positive bool) error {
+    switch r.overflow {
+    case Int64OverflowError:
+        return fmt.Errorf("%s value %s exceeds JavaScript safe integer range", name, raw)
+    case Int64OverflowClamp:
+        if positive {
+            r.dst = strconv.AppendInt(r.dst, maxSafeInteger, 10)
+        } else {
+            r.dst = strconv.AppendInt(r.dst, -maxSafeInteger, 10)
+        }
+    default:
+        r.dst = append(r.dst, raw...)
+    }
+    return nil
+}
+
+// isWellKnownJSON 判断是否为 protojson 使用特殊 JSON 格式的内置类型
+func isWellKnownJSON(name protoreflect.FullName) bool {
+    switch name {
+    case "google.protobuf.Any",
+        "google.protobuf.Timestamp",
+        "google.protobuf.Duration",
+        "google.protobuf.FieldMask",
+        "google.protobuf.Struct",
+        "google.protobuf.Value",
+        "google.protobuf.ListValue",
+        "google.protobuf.Empty",
+        "google.protobuf.BoolValue",
+        "google.protobuf.BytesValue",
+        "google.protobuf.DoubleValue",
+        "google.protobuf.FloatValue",
+        "google.protobuf.Int32Value",
+        "google.protobuf.Int64Value",
+        "google.protobuf.StringValue",
+        "google.protobuf.UInt32Value",
+        "google.protobuf.UInt64Value":
+        return true
+    }
+    return false
+}
+
+// stringEnd 返回从 start 处的引号开始的 JSON 字符串结束后的位置
+func stringEnd(data []byte, start int) int {
+    for i := start + 1; i < len(data); i++ {
+        switch data[i] {
+        case '\\':
+            i++
+        case '"':
+            return i + 1
+        }
+    }
+    return len(data)
+}
+
+func unquote(raw []byte) (string, error) {
+    if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+        inner := raw[1 : len(raw)-1]
+        escaped := false
+        for _, b := range inner {
+            if b == '\\' {
+                escaped = true
+                break
+            }
+        }
+        if !escaped {
+            return string(inner), nil
+        }
+    }
+    var s string
+    if err := json.Unmarshal(raw, &s); err != nil {
+        return "", fmt.Errorf("invalid json string %s: %w", raw, err)
+    }
+    return s, nil
+}
@@ -0,0 +1,139 @@
+package kitcodec
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoJSONCodec_Int64AsNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		overflow Int64Overflow
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "in range fields",
+			text: `user_id: 42 balance: 7 friend_ids: [1, -2] counters: { key: "a" value: 3 }`,
+			want: `{"userId":42,"balance":7,"friendIds":[1,-2],"counters":{"a":3}}`,
+		},
+		{
+			name: "max safe integer",
+			text: `user_id: 9007199254740991 balance: 9007199254740991`,
+			want: `{"userId":9007199254740991,"balance":9007199254740991}`,
+		},
+		{
+			name:     "out of range falls back to string",
+			text:     `user_id: 9007199254740992 friend_ids: [-9007199254740992, 1]`,
+			overflow: Int64OverflowString,
+			want:     `{"userId":"9007199254740992","friendIds":["-9007199254740992",1]}`,
+		},
+		{
+			name:     "out of range clamps",
+			text:     `user_id: -9223372036854775808 balance: 18446744073709551615`,
+			overflow: Int64OverflowClamp,
+			want:     `{"userId":-9007199254740991,"balance":9007199254740991}`,
+		},
+		{
+			name:     "out of range errors",
+			text:     `balance: 18446744073709551615`,
+			overflow: Int64OverflowError,
+			wantErr:  true,
+		},
+		{
+			name: "string fields untouched",
+			text: `user_name: "123" profile: { display_name: "456" tags: "789" }`,
+			want: `{"userName":"123","profile":{"displayName":"456","tags":["789"]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := newProtoJSONCodec(WithInt64AsNumber(tt.overflow))
+			got, err := codec.MarshalStable(mustUnmarshalText(t, "kitcodec.test.User", tt.text))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("MarshalStable() = %s, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MarshalStable() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("MarshalStable() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoJSONCodec_Int64AsNumberWellKnown(t *testing.T) {
+	codec := newProtoJSONCodec(WithInt64AsNumber(Int64OverflowString))
+	got, err := codec.MarshalStable(wrapperspb.Int64(-5))
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	if string(got) != `-5` {
+		t.Fatalf("MarshalStable(Int64Value) = %s, want -5", got)
+	}
+
+	inner := mustUnmarshalText(t, "kitcodec.test.User", `user_id: 9`)
+	extra, err := anypb.New(inner)
+	if err != nil {
+		t.Fatalf("anypb.New() error = %v", err)
+	}
+	msg := newTestMessage(t, "kitcodec.test.User")
+	msg.Set(msg.Descriptor().Fields().ByName("extra"), protoreflect.ValueOfMessage(extra.ProtoReflect()))
+	types := new(protoregistry.Types)
+	if err = types.RegisterMessage(dynamicpb.NewMessageType(inner.Descriptor())); err != nil {
+		t.Fatalf("RegisterMessage() error = %v", err)
+	}
+	codec = newProtoJSONCodec(WithInt64AsNumber(Int64OverflowString), WithResolver(types))
+	got, err = codec.MarshalStable(msg)
+	if err != nil {
+		t.Fatalf("MarshalStable() error = %v", err)
+	}
+	want := `{"extra":{"@type":"type.googleapis.com/kitcodec.test.User","userId":9}}`
+	if string(got) != want {
+		t.Fatalf("MarshalStable(Any) = %s, want %s", got, want)
+	}
+}
+
+func TestProtoJSONCodec_Int64AsNumberKeepsIndent(t *testing.T) {
+	codec := newProtoJSONCodec(WithInt64AsNumber(Int64OverflowString), WithIndent("\t"))
+	got, err := codec.Marshal(mustUnmarshalText(t, "kitcodec.test.User", `user_id: 1 user_name: "a"`))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(got), "\n\t\"userId\":") || !strings.Contains(string(got), "1,\n") {
+		t.Fatalf("Marshal() = %q, want indented output with numeric userId", got)
+	}
+
+	prefix := []byte("prefix")
+	appended, err := codec.MarshalAppend(prefix, mustUnmarshalText(t, "kitcodec.test.User", `user_id: 1`))
+	if err != nil {
+		t.Fatalf("MarshalAppend() error = %v", err)
+	}
+	if !strings.HasPrefix(string(appended), "prefix{") {
+		t.Fatalf("MarshalAppend() = %q, want prefix kept", appended)
+	}
+}
+
+func TestProtoJSONCodec_Int64UnmarshalNumberOrString(t *testing.T) {
+	codec := newProtoJSONCodec(WithInt64AsNumber(Int64OverflowString))
+	for _, input := range []string{`{"userId":42}`, `{"userId":"42"}`, `{"user_id":42}`} {
+		out := newTestMessage(t, "kitcodec.test.User")
+		if err := codec.Unmarshal([]byte(input), out); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", input, err)
+		}
+		if got := out.Get(out.Descriptor().Fields().ByName("user_id")).Int(); got != 42 {
+			t.Fatalf("Unmarshal(%s) user_id = %d, want 42", input, got)
+		}
+	}
+}
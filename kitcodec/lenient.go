@@ -0,0 +1,444 @@
+package kitcodec
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// AliasTable 按消息全名登记的字段别名, 用于兼容旧版本(如 Yii2)的字段名
+type AliasTable struct {
+    mu      sync.RWMutex
+    aliases map[protoreflect.FullName]map[string]protoreflect.Name
+}
+
+// NewAliasTable 创建字段别名表
+func NewAliasTable() *AliasTable {
+    return &AliasTable{aliases: make(map[protoreflect.FullName]map[string]protoreflect.Name)}
+}
+
+// Register 登记别名, message 为消息全名, field 为 proto 字段名
+// 示例:
+//
+//	aliases := kitcodec.NewAliasTable().
+//	    Register("user.v1.User", "uid", "user_id").
+//	    Register("user.v1.User", "UserName", "user_name")
+func (t *AliasTable) Register(message protoreflect.FullName, alias string, field protoreflect.Name) *AliasTable {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    fields := t.aliases[message]
+    if fields == nil {
+        fields = make(map[string]protoreflect.Name)
+        t.aliases[message] = fields
+    }
+    fields[alias] = field
+    return t
+}
+
+func (t *AliasTable) lookup(message protoreflect.FullName, alias string) (protoreflect.Name, bool) {
+    if t == nil {
+        return "", false
+    }
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+    field, ok := t.aliases[message][alias]
+    return field, ok
+}
+
+// AliasUsage 一次非标准字段名的命中记录, 可用于记录废弃字段名的使用情况
+type AliasUsage struct {
+    // Message 消息全名
+    Message protoreflect.FullName
+    // Key 请求中使用的字段名
+    Key string
+    // Field 实际对应的 proto 字段名
+    Field protoreflect.Name
+    // Registered 为 true 表示命中别名表, 否则为大小写/下划线形式不同的字段名
+    Registered bool
+}
+
+// WithLenientNames 解码时除 proto 字段名与 json_name 外, 还接受大小写、下划线形式不同的字段名
+// (如 user_name/userName/UserName/user-name) 以及 aliases 中登记的别名.
+// 多个键指向同一字段时解码失败. report 不为 nil 时, 每次命中非标准字段名都会回调一次.
+func WithLenientNames(aliases *AliasTable, report func(AliasUsage)) ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.lenientNames = true
+        c.aliases = aliases
+        c.reportAlias = report
+    }
+}
+
+// WithScalarCoercion 解码时按消息描述把字符串转换为目标字段的类型, 用于表单与查询参数:
+//   - bool 接受 1/0、true/false、on/off、yes/no
+//   - 枚举接受名称(不区分大小写)或数字
+//   - 数字字段去掉首尾空白, 空字符串视为未设置
+//   - repeated 字段接受单个值或逗号分隔的字符串
+//   - google.protobuf.Timestamp 接受 RFC3339 或 unix 秒数
+func WithScalarCoercion() ProtoJSONOption {
+    return func(c *protoJSONCodec) {
+        c.coerceScalars = true
+    }
+}
+
+// CoerceJSON 按 desc 对 data 做与 WithScalarCoercion 相同的类型转换, 返回新的 JSON
+// 示例:
+//
+//	data, err := kitcodec.CoerceJSON((&userv1.ListRequest{}).ProtoReflect().Descriptor(), []byte(`{"page":"2"}`))
+func CoerceJSON(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+    d := &lenientDecoder{coerce: true, resolver: protoregistry.GlobalTypes}
+    return d.normalize(desc, data)
+}
+
+// lenientDecoder 在交给 protojson 之前, 按消息描述规范化请求 JSON
+type lenientDecoder struct {
+    coerce   bool
+    names    bool
+    aliases  *AliasTable
+    report   func(AliasUsage)
+    resolver Resolver
+}
+
+// normalize 解析 data, 规范化后重新编码
+func (d *lenientDecoder) normalize(desc protoreflect.MessageDescriptor, data []byte) ([]byte, error) {
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.UseNumber()
+    var value any
+    if err := decoder.Decode(&value); err != nil {
+        return nil, err
+    }
+    // protojson 拒绝值之后的多余内容, 规范化后重新编码会丢掉它们, 这里需先行拒绝
+    if _, err := decoder.Token(); err != io.EOF {
+        return nil, fmt.Errorf("unexpected data after top-level JSON value")
+    }
+    value, err := d.message(desc, value)
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(value)
+}
+
+func (d *lenientDecoder) message(desc protoreflect.MessageDescriptor, value any) (any, error) {
+    if desc.FullName() == "google.protobuf.Any" {
+        return d.any(value)
+    }
+    if d.coerce {
+        switch {
+        case desc.FullName() == "google.protobuf.Timestamp":
+            return coerceTimestamp(value)
+        case isWrapper(desc.FullName()):
+            return coerceScalar(desc.Fields().ByName("value"), value)
+        }
+    }
+    object, ok := value.(map[string]any)
+    if !ok || isWellKnownJSON(desc.FullName()) {
+        return value, nil
+    }
+    out := make(map[string]any, len(object))
+    seen := make(map[string]string, len(object))
+    for key, v := range object {
+        fd := d.field(desc, key)
+        if fd == nil {
+            out[key] = v
+            continue
+        }
+        name := fd.JSONName()
+        if fd.IsExtension() {
+            name = key
+        }
+        // 多个键指向同一字段时报错, 与 protojson 对重复字段的处理一致, 避免结果取决于 map 遍历顺序
+        if prev, ok := seen[name]; ok {
+            first, second := prev, key
+            if second < first {
+                first, second = second, first
+            }
+            return nil, fmt.Errorf("%s: duplicate field, got both %q and %q", fd.FullName(), first, second)
+        }
+        seen[name] = key
+        converted, err := d.fieldValue(fd, v)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", fd.FullName(), err)
+        }
+        out[name] = converted
+    }
+    return out, nil
+}
+
+// field 按 标准名 -> 别名表 -> 宽松匹配 的顺序查找字段
+func (d *lenientDecoder) field(desc protoreflect.MessageDescriptor, key string) protoreflect.FieldDescriptor {
+    fields := desc.Fields()
+    if len(key) > 2 && key[0] == '[' && key[len(key)-1] == ']' {
+        xt, err := d.resolver.FindExtensionByName(protoreflect.FullName(key[1 : len(key)-1]))
+        if err != nil {
+            return nil
+        }
+        return xt.TypeDescriptor()
+    }
+    if fd := fields.ByJSONName(key); fd != nil {
+        return fd
+    }
+    if fd := fields.ByName(protoreflect.Name(key)); fd != nil {
+        return fd
+    }
+    if !d.names {
+        return nil
+    }
+    if name, ok := d.aliases.lookup(desc.FullName(), key); ok {
+        if fd := fields.ByName(name); fd != nil {
+            d.reportUsage(desc, key, fd, true)
+            return fd
+        }
+    }
+    folded := foldName(key)
+    for i := 0; i < fields.Len(); i++ {
+        fd := fields.Get(i)
+        if foldName(fd.JSONName()) == folded || foldName(string(fd.Name())) == folded {
+            d.reportUsage(desc, key, fd, false)
+            return fd
+        }
+    }
+    return nil
+}
+
+func (d *lenientDecoder) reportUsage(desc protoreflect.MessageDescriptor, key string, fd protoreflect.FieldDescriptor, registered bool) {
+    if d.report == nil {
+        return
+    }
+    d.report(AliasUsage{Message: desc.FullName(), Key: key, Field: fd.Name(), Registered: registered})
+}
+
+func (d *lenientDecoder) fieldValue(fd protoreflect.FieldDescriptor, value any) (any, error) {
+    switch {
+    case value == nil:
+        return nil, nil
+    case fd.IsMap():
+        object, ok := value.(map[string]any)
+        if !ok {
+            return value, nil
+        }
+        for k, v := range object {
+            converted, err := d.single(fd.MapValue(), v)
+            if err != nil {
+                return nil, err
+            }
+            object[k] = converted
+        }
+        return object, nil
+    case fd.IsList():
+        items, ok := value.([]any)
+        if !ok {
+            if !d.coerce {
+                return value, nil
+            }
+            items = splitList(value)
+        }
+        out := items[:0]
+        for _, item := range items {
+            converted, err := d.single(fd, item)
+            if err != nil {
+                return nil, err
+            }
+            if converted != nil {
+                out = append(out, converted)
+            }
+        }
+        return out, nil
+    default:
+        return d.single(fd, value)
+    }
+}
+
+func (d *lenientDecoder) single(fd protoreflect.FieldDescriptor, value any) (any, error) {
+    if fd.Message() != nil {
+        return d.message(fd.Message(), value)
+    }
+    if d.coerce {
+        return coerceScalar(fd, value)
+    }
+    return value, nil
+}
+
+// any 根据 @type 解析实际类型后继续规范化
+func (d *lenientDecoder) any(value any) (any, error) {
+    object, ok := value.(map[string]any)
+    if !ok {
+        return value, nil
+    }
+    typeURL, _ := object["@type"].(string)
+    mt, err := d.resolver.FindMessageByURL(typeURL)
+    if err != nil {
+        return value, nil
+    }
+    desc := mt.Descriptor()
+    if isWellKnownJSON(desc.FullName()) {
+        if inner, ok := object["value"]; ok {
+            converted, err := d.message(desc, inner)
+            if err != nil {
+                return nil, err
+            }
+            object["value"] = converted
+        }
+        return object, nil
+    }
+    fields := make(map[string]any, len(object))
+    for k, v := range object {
+        if k != "@type" {
+            fields[k] = v
+        }
+    }
+    converted, err := d.message(desc, fields)
+    if err != nil {
+        return nil, err
+    }
+    out := converted.(map[string]any)
+    out["@type"] = typeURL
+    return out, nil
+}
+
+// foldName 忽略大小写、下划线与连字符后的字段名
+func foldName(name string) string {
+    var b strings.Builder
+    b.Grow(len(name))
+    for i := 0; i < len(name); i++ {
+        c := name[i]
+        switch {
+        case c == '_' || c == '-':
+            continue
+        case 'A' <= c && c <= 'Z':
+            c += 'a' - 'A'
+        }
+        b.WriteByte(c)
+    }
+    return b.String()
+}
+
+func (c *protoJSONCodec) lenientDecoder() *lenientDecoder {
+    resolver := c.resolver
+    if resolver == nil {
+        resolver = protoregistry.GlobalTypes
+    }
+    return &lenientDecoder{
+        coerce:   c.coerceScalars,
+        names:    c.lenientNames,
+        aliases:  c.aliases,
+        report:   c.reportAlias,
+        resolver: resolver,
+    }
+}
+
+// splitList 将单个值转换为列表, 字符串按逗号拆分
+func splitList(value any) []any {
+    s, ok := value.(string)
+    if !ok {
+        return []any{value}
+    }
+    if strings.TrimSpace(s) == "" {
+        return []any{}
+    }
+    parts := strings.Split(s, ",")
+    items := make([]any, len(parts))
+    for i, part := range parts {
+        items[i] = strings.TrimSpace(part)
+    }
+    return items
+}
+
+// coerceScalar 将字符串等宽松输入转换为 protojson 可以接受的标量, 无法识别的值原样返回交由 protojson 报错
+func coerceScalar(fd protoreflect.FieldDescriptor, value any) (any, error) {
+    switch fd.Kind() {
+    case protoreflect.StringKind, protoreflect.BytesKind:
+        return value, nil
+    case protoreflect.BoolKind:
+        switch v := value.(type) {
+        case string:
+            switch strings.ToLower(strings.TrimSpace(v)) {
+            case "1", "true", "on", "yes", "y":
+                return true, nil
+            case "0", "false", "off", "no", "n":
+                return false, nil
+            case "":
+                return nil, nil
+            }
+        case json.Number:
+            switch v {
+            case "1":
+                return true, nil
+            case "0":
+                return false, nil
+            }
+        }
+        return value, nil
+    case protoreflect.EnumKind:
+        s, ok := value.(string)
+        if !ok {
+            return value, nil
+        }
+        s = strings.TrimSpace(s)
+        if s == "" {
+            return nil, nil
+        }
+        if _, err := toInt64(s); err == nil && isJSONNumber(s) {
+            return json.Number(s), nil
+        }
+        values := fd.Enum().Values()
+        if values.ByName(protoreflect.Name(s)) != nil {
+            return s, nil
+        }
+        for i := 0; i < values.Len(); i++ {
+            if name := string(values.Get(i).Name()); strings.EqualFold(name, s) {
+                return name, nil
+            }
+        }
+        return s, nil
+    default:
+        s, ok := value.(string)
+        if !ok {
+            return value, nil
+        }
+        s = strings.TrimSpace(s)
+        if s == "" {
+            return nil, nil
+        }
+        // ParseFloat 还接受 NaN、Infinity、+5、0x1p-2 等, 这些不是 JSON 数字, 原样交给 protojson 处理
+        if isJSONNumber(s) {
+            return json.Number(s), nil
+        }
+        return s, nil
+    }
+}
+
+// jsonNumberPattern RFC 8259 中的数字字面量
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// isJSONNumber 判断 s 能否作为 json.Number 输出
+func isJSONNumber(s string) bool {
+    return jsonNumberPattern.MatchString(s)
+}
+
+// coerceTimestamp 将 unix 秒数转换为 RFC3339 字符串
+func coerceTimestamp(value any) (any, error) {
+    var raw string
+    switch v := value.(type) {
+    case string:
+        raw = strings.TrimSpace(v)
+        if raw == "" {
+            return nil, nil
+        }
+    case json.Number:
+        raw = string(v)
+    default:
+        return value, nil
+    }
+    seconds, err := toInt64(raw)
+    if err != nil {
+        return raw, nil
+    }
+    return time.Unix(seconds, 0).UTC().Format(time.RFC3339), nil
+}
@@ -0,0 +1,130 @@
+package kitcodec
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestProtoJSONCodec_LenientNames(t *testing.T) {
+	aliases := NewAliasTable().
+		Register("kitcodec.test.User", "uid", "user_id").
+		Register("kitcodec.test.Profile", "nick", "display_name")
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantUsage []AliasUsage
+	}{
+		{
+			name:  "standard names",
+			input: `{"userId":"1","user_name":"a"}`,
+			want:  `{"userId":"1","userName":"a"}`,
+		},
+		{
+			name:  "case variants",
+			input: `{"UserId":"1","USER_NAME":"a","friend-ids":["2"]}`,
+			want:  `{"userId":"1","userName":"a","friendIds":["2"]}`,
+			wantUsage: []AliasUsage{
+				{Message: "kitcodec.test.User", Key: "UserId", Field: "user_id"},
+				{Message: "kitcodec.test.User", Key: "USER_NAME", Field: "user_name"},
+				{Message: "kitcodec.test.User", Key: "friend-ids", Field: "friend_ids"},
+			},
+		},
+		{
+			name:  "registered alias",
+			input: `{"uid":"7"}`,
+			want:  `{"userId":"7"}`,
+			wantUsage: []AliasUsage{
+				{Message: "kitcodec.test.User", Key: "uid", Field: "user_id", Registered: true},
+			},
+		},
+		{
+			name:  "nested and repeated messages",
+			input: `{"Profile":{"nick":"n","child":{"DisplayName":"c"}},"profiles":[{"display-name":"p"}]}`,
+			want:  `{"profile":{"displayName":"n","child":{"displayName":"c"}},"profiles":[{"displayName":"p"}]}`,
+			wantUsage: []AliasUsage{
+				{Message: "kitcodec.test.Profile", Key: "DisplayName", Field: "display_name"},
+				{Message: "kitcodec.test.Profile", Key: "display-name", Field: "display_name"},
+				{Message: "kitcodec.test.Profile", Key: "nick", Field: "display_name", Registered: true},
+				{Message: "kitcodec.test.User", Key: "Profile", Field: "profile"},
+			},
+		},
+		{
+			name:  "unknown keys are still discarded",
+			input: `{"nickname":"x","userName":"a"}`,
+			want:  `{"userName":"a"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usages []AliasUsage
+			codec := newProtoJSONCodec(WithLenientNames(aliases, func(u AliasUsage) {
+				usages = append(usages, u)
+			}))
+			out := newTestMessage(t, "kitcodec.test.User")
+			if err := codec.Unmarshal([]byte(tt.input), out); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			got, err := codec.MarshalStable(out)
+			if err != nil {
+				t.Fatalf("MarshalStable() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("decoded = %s, want %s", got, tt.want)
+			}
+			sort.Slice(usages, func(i, j int) bool {
+				if usages[i].Message != usages[j].Message {
+					return usages[i].Message < usages[j].Message
+				}
+				return usages[i].Key < usages[j].Key
+			})
+			sort.Slice(tt.wantUsage, func(i, j int) bool {
+				if tt.wantUsage[i].Message != tt.wantUsage[j].Message {
+					return tt.wantUsage[i].Message < tt.wantUsage[j].Message
+				}
+				return tt.wantUsage[i].Key < tt.wantUsage[j].Key
+			})
+			if len(usages) != 0 || len(tt.wantUsage) != 0 {
+				if !reflect.DeepEqual(usages, tt.wantUsage) {
+					t.Fatalf("usages = %+v, want %+v", usages, tt.wantUsage)
+				}
+			}
+		})
+	}
+}
+
+func TestProtoJSONCodec_StrictNamesByDefault(t *testing.T) {
+	out := newTestMessage(t, "kitcodec.test.User")
+	if err := newProtoJSONCodec().Unmarshal([]byte(`{"UserName":"a"}`), out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := out.Get(out.Descriptor().Fields().ByName("user_name")).String(); got != "" {
+		t.Fatalf("user_name = %q, want empty without lenient names", got)
+	}
+}
+
+func TestProtoJSONCodec_LenientNamesRejectsInvalidInput(t *testing.T) {
+	aliases := NewAliasTable().Register("kitcodec.test.User", "uid", "user_id")
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "standard name and alias", input: `{"uid":"7","user_id":"8"}`},
+		{name: "alias and case variant", input: `{"uid":"7","UserId":"8"}`},
+		{name: "two case variants", input: `{"USER_ID":"7","UserId":"8"}`},
+		{name: "nested duplicate", input: `{"profile":{"displayName":"a","DISPLAY_NAME":"b"}}`},
+		{name: "trailing value", input: `{"userId":"1"} {"userId":"2"}`},
+		{name: "trailing garbage", input: `{"userId":"1"}x`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := newProtoJSONCodec(WithLenientNames(aliases, nil))
+			out := newTestMessage(t, "kitcodec.test.User")
+			if err := codec.Unmarshal([]byte(tt.input), out); err == nil {
+				t.Fatalf("Unmarshal(%s) error = nil, want error", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package kitcodec
+
+import (
+    "bytes"
+    "fmt"
+
+    "connectrpc.com/connect"
+    "github.com/vmihailenco/msgpack/v5"
+    "google.golang.org/protobuf/proto"
+)
+
+const codecNameMsgPack = "msgpack"
+
+// msgPackCodec 通过 protoreflect 将 proto 消息映射为 MessagePack
+type msgPackCodec struct {
+    binaryCodecOptions
+}
+
+var (
+    _ marshalAppender = (*msgPackCodec)(nil)
+    _ stableCodec     = (*msgPackCodec)(nil)
+)
+
+// WithMsgPack 注册名为 msgpack 的编解码器, Content-Type 为 application/msgpack
+func WithMsgPack(opts ...BinaryCodecOption) connect.Option {
+    c := &msgPackCodec{}
+    c.apply(opts)
+    return connect.WithCodec(c)
+}
+
+func (c *msgPackCodec) Name() string { return codecNameMsgPack }
+
+func (c *msgPackCodec) Marshal(message any) ([]byte, error) {
+    return c.MarshalAppend(nil, message)
+}
+
+func (c *msgPackCodec) MarshalAppend(dst []byte, message any) ([]byte, error) {
+    return c.marshal(dst, message, false)
+}
+
+func (c *msgPackCodec) MarshalStable(message any) ([]byte, error) {
+    return c.marshal(nil, message, true)
+}
+
+func (c *msgPackCodec) marshal(dst []byte, message any, stable bool) ([]byte, error) {
+    protoMessage, ok := message.(proto.Message)
+    if !ok {
+        return nil, errNotProto(message)
+    }
+    value, err := c.mapper().messageToValue(protoMessage.ProtoReflect())
+    if err != nil {
+        return nil, err
+    }
+    buf := bytes.NewBuffer(dst)
+    enc := msgpack.NewEncoder(buf)
+    enc.SetSortMapKeys(stable)
+    if err = enc.Encode(value); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func (c *msgPackCodec) Unmarshal(binary []byte, message any) error {
+    protoMessage, ok := message.(proto.Message)
+    if !ok {
+        return errNotProto(message)
+    }
+    if len(binary) == 0 {
+        return nil
+    }
+    var value any
+    if err := msgpack.Unmarshal(binary, &value); err != nil {
+        return fmt.Errorf("unmarshal into %T: %w", message, err)
+    }
+    if err := c.mapper().valueToMessage(value, protoMessage.ProtoReflect()); err != nil {
+        return fmt.Errorf("unmarshal into %T: %w", message, err)
+    }
+    return nil
+}
+
+func (c *msgPackCodec) IsBinary() bool {
+    return true
+}
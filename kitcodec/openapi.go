@@ -0,0 +1,478 @@
+package kitcodec
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/reflect/protoregistry"
+    "google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+    openAPIVersion       = "3.1.0"
+    openAPIErrorSchema   = "connect.error"
+    openAPISecurityName  = "bearerAuth"
+    openAPISchemaRefBase = "#/components/schemas/"
+    jsonSchemaDialect    = "https://json-schema.org/draft/2020-12/schema"
+    jsonSchemaRefBase    = "#/$defs/"
+)
+
+// OpenAPIRoute 一条已挂载的 Connect 路由
+type OpenAPIRoute struct {
+    // Method 为 http.MethodPost 或 http.MethodGet
+    Method string
+    // Path 为服务前缀 /package.Service/ 或单个方法 /package.Service/Method, 允许带 gin 的 *any 后缀
+    Path string
+    // Auth 为 true 时在文档中标记需要 Bearer 认证
+    Auth bool
+}
+
+// DescriptorResolver 按全名查找服务描述, protoregistry.Files 即满足该接口
+type DescriptorResolver interface {
+    FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
+// OpenAPIOption 配置 OpenAPI 文档生成
+type OpenAPIOption func(*openAPIGenerator)
+
+// WithOpenAPIInfo 设置文档的标题与版本, 默认 "API" 与 "1.0.0"
+func WithOpenAPIInfo(title, version string) OpenAPIOption {
+    return func(g *openAPIGenerator) {
+        g.title = title
+        g.version = version
+    }
+}
+
+// WithOpenAPIServers 设置文档的服务地址
+func WithOpenAPIServers(urls ...string) OpenAPIOption {
+    return func(g *openAPIGenerator) {
+        g.servers = urls
+    }
+}
+
+// WithOpenAPIJSONOptions 使用与编解码器相同的配置描述 JSON 结构,
+// 影响字段名(WithUseProtoNames)、枚举(WithUseEnumNumbers)与 64 位整数(WithInt64AsNumber)的表示
+// 示例:
+//
+//	jsonOpts := []kitcodec.ProtoJSONOption{kitcodec.WithUseProtoNames()}
+//	kitrouter.WithCodec(kitcodec.WithProtoJSONOptions(jsonOpts...))
+//	kitrouter.WithOpenAPI("/openapi.json", kitcodec.WithOpenAPIJSONOptions(jsonOpts...))
+func WithOpenAPIJSONOptions(opts ...ProtoJSONOption) OpenAPIOption {
+    return func(g *openAPIGenerator) {
+        g.codec = newProtoJSONCodec(opts...)
+    }
+}
+
+// WithOpenAPIFiles 使用自定义的描述注册表查找服务, 默认为 protoregistry.GlobalFiles
+func WithOpenAPIFiles(files DescriptorResolver) OpenAPIOption {
+    return func(g *openAPIGenerator) {
+        g.files = files
+    }
+}
+
+// GenerateOpenAPI 根据路由对应的 proto 服务描述生成 OpenAPI 3.1 文档(JSON).
+//   - POST 路由生成以 JSON 请求体调用的操作
+//   - GET 路由按 kitctx.GinMiddlewareAdapterMethodGet 的映射生成查询参数, 消息与 map 字段使用 deepObject 形式;
+//     路由为服务前缀时只为 idempotency_level = NO_SIDE_EFFECTS 的方法生成
+//   - 流式方法无法用 OpenAPI 描述, 跳过; 在注册表中找不到的服务同样跳过
+//   - 字段上的 protovalidate 规则转换为对应的 JSON Schema 关键字, 见 GenerateJSONSchema
+func GenerateOpenAPI(routes []OpenAPIRoute, opts ...OpenAPIOption) ([]byte, error) {
+    g := newOpenAPIGenerator(openAPISchemaRefBase, opts)
+    paths := make(map[string]map[string]any)
+    secured := false
+    for _, route := range routes {
+        for _, method := range g.methods(route) {
+            path := "/" + string(method.Parent().FullName()) + "/" + string(method.Name())
+            if paths[path] == nil {
+                paths[path] = make(map[string]any)
+            }
+            paths[path][strings.ToLower(route.Method)] = g.operation(method, route)
+            secured = secured || route.Auth
+        }
+    }
+    g.schemas[openAPIErrorSchema] = connectErrorSchema()
+    components := map[string]any{"schemas": g.schemas}
+    if secured {
+        components["securitySchemes"] = map[string]any{
+            openAPISecurityName: map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+        }
+    }
+    doc := map[string]any{
+        "openapi":    openAPIVersion,
+        "info":       map[string]any{"title": g.title, "version": g.version},
+        "paths":      paths,
+        "components": components,
+    }
+    if len(g.servers) > 0 {
+        servers := make([]any, 0, len(g.servers))
+        for _, url := range g.servers {
+            servers = append(servers, map[string]any{"url": url})
+        }
+        doc["servers"] = servers
+    }
+    return json.Marshal(doc)
+}
+
+// GenerateJSONSchema 生成单个消息的 JSON Schema(2020-12), 引用的消息放在 $defs 中.
+// 字段上的 protovalidate 规则会转换为 minLength、pattern、minimum、enum、required 等关键字,
+// 可供前端表单在提交前按相同的规则校验. 支持 WithOpenAPIJSONOptions 配置 JSON 结构
+func GenerateJSONSchema(desc protoreflect.MessageDescriptor, opts ...OpenAPIOption) ([]byte, error) {
+    g := newOpenAPIGenerator(jsonSchemaRefBase, opts)
+    root := g.messageSchema(desc)
+    root["$schema"] = jsonSchemaDialect
+    if len(g.schemas) > 0 {
+        root["$defs"] = g.schemas
+    }
+    return json.Marshal(root)
+}
+
+type openAPIGenerator struct {
+    title   string
+    version string
+    servers []string
+    files   DescriptorResolver
+    codec   *protoJSONCodec
+    refBase string
+    schemas map[string]any
+}
+
+func newOpenAPIGenerator(refBase string, opts []OpenAPIOption) *openAPIGenerator {
+    g := &openAPIGenerator{
+        title:   "API",
+        version: "1.0.0",
+        files:   protoregistry.GlobalFiles,
+        codec:   newProtoJSONCodec(),
+        refBase: refBase,
+        schemas: make(map[string]any),
+    }
+    for _, opt := range opts {
+        opt(g)
+    }
+    return g
+}
+
+// methods 解析路由对应的方法, 跳过流式方法
+func (g *openAPIGenerator) methods(route OpenAPIRoute) []protoreflect.MethodDescriptor {
+    name := strings.Trim(strings.TrimSuffix(route.Path, "*any"), "/")
+    serviceName, methodName, _ := strings.Cut(name, "/")
+    desc, err := g.files.FindDescriptorByName(protoreflect.FullName(serviceName))
+    if err != nil {
+        return nil
+    }
+    service, ok := desc.(protoreflect.ServiceDescriptor)
+    if !ok {
+        return nil
+    }
+    var methods []protoreflect.MethodDescriptor
+    for i := 0; i < service.Methods().Len(); i++ {
+        method := service.Methods().Get(i)
+        if method.IsStreamingClient() || method.IsStreamingServer() {
+            continue
+        }
+        if methodName != "" && string(method.Name()) != methodName {
+            continue
+        }
+        if route.Method == http.MethodGet && methodName == "" && !noSideEffects(method) {
+            continue
+        }
+        methods = append(methods, method)
+    }
+    return methods
+}
+
+func noSideEffects(method protoreflect.MethodDescriptor) bool {
+    options, ok := method.Options().(*descriptorpb.MethodOptions)
+    return ok && options.GetIdempotencyLevel() == descriptorpb.MethodOptions_NO_SIDE_EFFECTS
+}
+
+func (g *openAPIGenerator) operation(method protoreflect.MethodDescriptor, route OpenAPIRoute) map[string]any {
+    op := map[string]any{
+        "operationId": string(method.FullName()),
+        "tags":        []any{string(method.Parent().FullName())},
+        "responses": map[string]any{
+            "200": map[string]any{
+                "description": "OK",
+                "content":     jsonContent(g.messageSchema(method.Output())),
+            },
+            "default": map[string]any{
+                "description": "Connect error",
+                "content":     jsonContent(g.schemaRef(openAPIErrorSchema)),
+            },
+        },
+    }
+    if comment := descriptorComment(method); comment != "" {
+        op["description"] = comment
+    }
+    if route.Method == http.MethodGet {
+        op["parameters"] = g.queryParameters(method.Input())
+    } else {
+        op["requestBody"] = map[string]any{
+            "required": true,
+            "content":  jsonContent(g.messageSchema(method.Input())),
+        }
+    }
+    if route.Auth {
+        op["security"] = []any{map[string]any{openAPISecurityName: []any{}}}
+    }
+    return op
+}
+
+// queryParameters 描述 GET 请求的查询参数, 与 GinMiddlewareAdapterMethodGet 一致:
+// 每个参数对应一个顶层字段, 重复的参数组成 repeated 字段, 消息与 map 字段写作 filter[status]=1;
+// repeated 消息字段(items[0].name)无法用 OpenAPI 参数描述, 跳过
+func (g *openAPIGenerator) queryParameters(desc protoreflect.MessageDescriptor) []any {
+    fields := desc.Fields()
+    parameters := make([]any, 0, fields.Len())
+    for i := 0; i < fields.Len(); i++ {
+        fd := fields.Get(i)
+        object := fd.IsMap() || (fd.Message() != nil && !isQueryMessage(fd.Message().FullName()))
+        if object && fd.IsList() && !fd.IsMap() {
+            continue
+        }
+        parameter := map[string]any{
+            "name":   g.fieldName(fd),
+            "in":     "query",
+            "schema": g.fieldSchema(fd),
+        }
+        if fieldRequired(fd) {
+            parameter["required"] = true
+        }
+        switch {
+        case object:
+            parameter["style"] = "deepObject"
+            parameter["explode"] = true
+        case fd.IsList():
+            parameter["style"] = "form"
+            parameter["explode"] = true
+        }
+        if comment := descriptorComment(fd); comment != "" {
+            parameter["description"] = comment
+        }
+        parameters = append(parameters, parameter)
+    }
+    return parameters
+}
+
+// isQueryMessage 判断 JSON 表示为字符串或标量、可以放在查询参数中的消息类型
+func isQueryMessage(name protoreflect.FullName) bool {
+    switch name {
+    case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask":
+        return true
+    }
+    return isWrapper(name)
+}
+
+func (g *openAPIGenerator) fieldName(fd protoreflect.FieldDescriptor) string {
+    if g.codec.marshal.UseProtoNames {
+        return string(fd.Name())
+    }
+    return fd.JSONName()
+}
+
+// messageSchema 返回消息的 schema 引用, 首次遇到时登记到 components
+func (g *openAPIGenerator) messageSchema(desc protoreflect.MessageDescriptor) map[string]any {
+    if schema := g.wellKnownSchema(desc.FullName()); schema != nil {
+        return schema
+    }
+    name := string(desc.FullName())
+    if _, ok := g.schemas[name]; ok {
+        return g.schemaRef(name)
+    }
+    // 先占位, 防止递归消息无限展开
+    g.schemas[name] = nil
+    fields := desc.Fields()
+    properties := make(map[string]any, fields.Len())
+    var required []any
+    for i := 0; i < fields.Len(); i++ {
+        fd := fields.Get(i)
+        properties[g.fieldName(fd)] = g.fieldSchema(fd)
+        if fieldRequired(fd) {
+            required = append(required, g.fieldName(fd))
+        }
+    }
+    schema := map[string]any{
+        "type":       "object",
+        "properties": properties,
+    }
+    if len(required) > 0 {
+        schema["required"] = required
+    }
+    if comment := descriptorComment(desc); comment != "" {
+        schema["description"] = comment
+    }
+    g.schemas[name] = schema
+    return g.schemaRef(name)
+}
+
+func (g *openAPIGenerator) fieldSchema(fd protoreflect.FieldDescriptor) map[string]any {
+    var schema map[string]any
+    switch {
+    case fd.IsMap():
+        schema = map[string]any{
+            "type":                 "object",
+            "additionalProperties": g.singleSchema(fd.MapValue()),
+        }
+    case fd.IsList():
+        schema = map[string]any{
+            "type":  "array",
+            "items": g.singleSchema(fd),
+        }
+    default:
+        schema = g.singleSchema(fd)
+    }
+    g.applyFieldRules(fd, schema)
+    if comment := descriptorComment(fd); comment != "" && schema["$ref"] == nil {
+        // 保留规则生成的说明, 如字符串编码的 64 位整数的取值范围
+        if rules, ok := schema["description"].(string); ok {
+            comment += "\n\n" + rules
+        }
+        schema["description"] = comment
+    }
+    return schema
+}
+
+func (g *openAPIGenerator) singleSchema(fd protoreflect.FieldDescriptor) map[string]any {
+    switch fd.Kind() {
+    case protoreflect.MessageKind, protoreflect.GroupKind:
+        return g.messageSchema(fd.Message())
+    case protoreflect.EnumKind:
+        return g.enumSchema(fd.Enum())
+    case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+        return g.int64Schema("int64")
+    case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+        return g.int64Schema("uint64")
+    }
+    return scalarSchema(fd.Kind())
+}
+
+// int64Schema 默认与 protojson 一致输出为字符串, WithInt64AsNumber 时为数字,
+// 超出安全范围仍输出字符串时两者皆有可能
+func (g *openAPIGenerator) int64Schema(format string) map[string]any {
+    if !g.codec.int64AsNumber {
+        return map[string]any{"type": "string", "format": format}
+    }
+    if g.codec.int64Overflow == Int64OverflowString {
+        return map[string]any{"type": []any{"integer", "string"}, "format": format}
+    }
+    return map[string]any{"type": "integer", "format": format}
+}
+
+func (g *openAPIGenerator) enumSchema(desc protoreflect.EnumDescriptor) map[string]any {
+    if desc.FullName() == "google.protobuf.NullValue" {
+        return map[string]any{"type": "null"}
+    }
+    values := desc.Values()
+    enum := make([]any, 0, values.Len())
+    for i := 0; i < values.Len(); i++ {
+        if g.codec.marshal.UseEnumNumbers {
+            enum = append(enum, values.Get(i).Number())
+        } else {
+            enum = append(enum, string(values.Get(i).Name()))
+        }
+    }
+    if g.codec.marshal.UseEnumNumbers {
+        return map[string]any{"type": "integer", "enum": enum}
+    }
+    return map[string]any{"type": "string", "enum": enum}
+}
+
+func scalarSchema(kind protoreflect.Kind) map[string]any {
+    switch kind {
+    case protoreflect.BoolKind:
+        return map[string]any{"type": "boolean"}
+    case protoreflect.StringKind:
+        return map[string]any{"type": "string"}
+    case protoreflect.BytesKind:
+        return map[string]any{"type": "string", "contentEncoding": "base64"}
+    case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+        return map[string]any{"type": "integer", "format": "int32"}
+    case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+        return map[string]any{"type": "integer", "format": "uint32", "minimum": 0}
+    case protoreflect.FloatKind:
+        return map[string]any{"type": "number", "format": "float"}
+    case protoreflect.DoubleKind:
+        return map[string]any{"type": "number", "format": "double"}
+    }
+    return map[string]any{}
+}
+
+// wellKnownSchema 返回 protojson 对内置类型使用的特殊 JSON 格式, 其余类型返回 nil
+func (g *openAPIGenerator) wellKnownSchema(name protoreflect.FullName) map[string]any {
+    switch name {
+    case "google.protobuf.Timestamp":
+        return map[string]any{"type": "string", "format": "date-time"}
+    case "google.protobuf.Duration":
+        return map[string]any{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?s$`}
+    case "google.protobuf.FieldMask":
+        return map[string]any{"type": "string"}
+    case "google.protobuf.Struct", "google.protobuf.Empty":
+        return map[string]any{"type": "object"}
+    case "google.protobuf.ListValue":
+        return map[string]any{"type": "array"}
+    case "google.protobuf.Value":
+        return map[string]any{}
+    case "google.protobuf.Any":
+        return map[string]any{
+            "type":       "object",
+            "properties": map[string]any{"@type": map[string]any{"type": "string"}},
+            "required":   []any{"@type"},
+        }
+    case "google.protobuf.BoolValue":
+        return scalarSchema(protoreflect.BoolKind)
+    case "google.protobuf.StringValue":
+        return scalarSchema(protoreflect.StringKind)
+    case "google.protobuf.BytesValue":
+        return scalarSchema(protoreflect.BytesKind)
+    case "google.protobuf.Int32Value":
+        return scalarSchema(protoreflect.Int32Kind)
+    case "google.protobuf.UInt32Value":
+        return scalarSchema(protoreflect.Uint32Kind)
+    case "google.protobuf.FloatValue":
+        return scalarSchema(protoreflect.FloatKind)
+    case "google.protobuf.DoubleValue":
+        return scalarSchema(protoreflect.DoubleKind)
+    case "google.protobuf.Int64Value":
+        return g.int64Schema("int64")
+    case "google.protobuf.UInt64Value":
+        return g.int64Schema("uint64")
+    }
+    return nil
+}
+
+// connectErrorSchema Connect 协议的错误响应
+func connectErrorSchema() map[string]any {
+    return map[string]any{
+        "type": "object",
+        "properties": map[string]any{
+            "code":    map[string]any{"type": "string"},
+            "message": map[string]any{"type": "string"},
+            "details": map[string]any{
+                "type": "array",
+                "items": map[string]any{
+                    "type": "object",
+                    "properties": map[string]any{
+                        "type":  map[string]any{"type": "string"},
+                        "value": map[string]any{"type": "string", "contentEncoding": "base64"},
+                        "debug": map[string]any{},
+                    },
+                },
+            },
+        },
+    }
+}
+
+func (g *openAPIGenerator) schemaRef(name string) map[string]any {
+    return map[string]any{"$ref": g.refBase + name}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+    return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// descriptorComment 读取 proto 源文件中的注释, 编译时未保留源码信息则为空
+func descriptorComment(desc protoreflect.Descriptor) string {
+    location := desc.ParentFile().SourceLocations().ByDescriptor(desc)
+    return strings.TrimSpace(location.LeadingComments)
+}
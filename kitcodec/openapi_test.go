@@ -0,0 +1,280 @@
+package kitcodec
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// generateTestOpenAPI 为测试服务生成文档并解析为 map
+func generateTestOpenAPI(t *testing.T, routes []OpenAPIRoute, opts ...OpenAPIOption) map[string]any {
+	t.Helper()
+	opts = append([]OpenAPIOption{WithOpenAPIFiles(testFiles(t))}, opts...)
+	data, err := GenerateOpenAPI(routes, opts...)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return doc
+}
+
+// lookup 按路径读取嵌套的 map 值
+func lookup(t *testing.T, value any, keys ...string) any {
+	t.Helper()
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			t.Fatalf("lookup %v: %q is not an object", keys, key)
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func TestGenerateOpenAPI_Paths(t *testing.T) {
+	doc := generateTestOpenAPI(t, []OpenAPIRoute{
+		{Method: http.MethodPost, Path: "/kitcodec.test.UserService/*any", Auth: true},
+		{Method: http.MethodGet, Path: "/kitcodec.test.UserService/"},
+		{Method: http.MethodPost, Path: "/unknown.v1.Service/*any"},
+	}, WithOpenAPIInfo("Test", "2.0.0"))
+
+	if got := doc["openapi"]; got != "3.1.0" {
+		t.Errorf("openapi = %v", got)
+	}
+	if got := lookup(t, doc, "info", "title"); got != "Test" {
+		t.Errorf("title = %v", got)
+	}
+	paths := doc["paths"].(map[string]any)
+	if len(paths) != 2 {
+		t.Fatalf("paths = %v, want GetUser and UpdateUser", reflect.ValueOf(paths).MapKeys())
+	}
+	getUser := paths["/kitcodec.test.UserService/GetUser"].(map[string]any)
+	if getUser["post"] == nil || getUser["get"] == nil {
+		t.Errorf("GetUser operations = %v, want post and get", reflect.ValueOf(getUser).MapKeys())
+	}
+	updateUser := paths["/kitcodec.test.UserService/UpdateUser"].(map[string]any)
+	if updateUser["get"] != nil {
+		t.Error("UpdateUser has side effects and must not be exposed via GET")
+	}
+	if got := lookup(t, updateUser, "post", "responses", "200", "content", "application/json", "schema", "$ref"); got != "#/components/schemas/kitcodec.test.Profile" {
+		t.Errorf("UpdateUser response = %v", got)
+	}
+	if got := lookup(t, updateUser, "post", "security"); got == nil {
+		t.Error("auth route without security requirement")
+	}
+	if got := lookup(t, getUser, "get", "security"); got != nil {
+		t.Errorf("guest route security = %v", got)
+	}
+	if got := lookup(t, doc, "components", "securitySchemes", "bearerAuth", "scheme"); got != "bearer" {
+		t.Errorf("security scheme = %v", got)
+	}
+}
+
+func TestGenerateOpenAPI_Schema(t *testing.T) {
+	doc := generateTestOpenAPI(t, []OpenAPIRoute{{Method: http.MethodPost, Path: "/kitcodec.test.UserService/UpdateUser"}})
+	user := lookup(t, doc, "components", "schemas", "kitcodec.test.User", "properties")
+	tests := []struct {
+		keys []string
+		want any
+	}{
+		{[]string{"userId", "type"}, "string"},
+		{[]string{"userId", "format"}, "int64"},
+		{[]string{"balance", "format"}, "uint64"},
+		{[]string{"userName", "type"}, "string"},
+		{[]string{"status", "enum"}, []any{"STATUS_UNSPECIFIED", "STATUS_ACTIVE", "STATUS_BANNED"}},
+		{[]string{"active", "type"}, "boolean"},
+		{[]string{"scores", "items", "format"}, "int32"},
+		{[]string{"profile", "$ref"}, "#/components/schemas/kitcodec.test.Profile"},
+		{[]string{"createdAt", "format"}, "date-time"},
+		{[]string{"counters", "additionalProperties", "type"}, "string"},
+		{[]string{"extra", "required"}, []any{"@type"}},
+		{[]string{"readMask", "type"}, "string"},
+	}
+	for _, tt := range tests {
+		if got := lookup(t, user, tt.keys...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v = %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+	// 递归消息引用自身
+	if got := lookup(t, doc, "components", "schemas", "kitcodec.test.Profile", "properties", "child", "$ref"); got != "#/components/schemas/kitcodec.test.Profile" {
+		t.Errorf("Profile.child = %v", got)
+	}
+	if got := lookup(t, doc, "components", "schemas", "connect.error", "properties", "code", "type"); got != "string" {
+		t.Errorf("connect.error code = %v", got)
+	}
+}
+
+func TestGenerateOpenAPI_JSONOptions(t *testing.T) {
+	doc := generateTestOpenAPI(t,
+		[]OpenAPIRoute{{Method: http.MethodPost, Path: "/kitcodec.test.UserService/UpdateUser"}},
+		WithOpenAPIJSONOptions(WithUseProtoNames(), WithUseEnumNumbers(), WithInt64AsNumber(Int64OverflowError)),
+	)
+	user := lookup(t, doc, "components", "schemas", "kitcodec.test.User", "properties")
+	if got := lookup(t, user, "user_id", "type"); got != "integer" {
+		t.Errorf("user_id type = %v", got)
+	}
+	if got := lookup(t, user, "status", "enum"); !reflect.DeepEqual(got, []any{0.0, 1.0, 2.0}) {
+		t.Errorf("status enum = %v", got)
+	}
+	if got := lookup(t, user, "userId"); got != nil {
+		t.Errorf("json name present with UseProtoNames: %v", got)
+	}
+
+	doc = generateTestOpenAPI(t,
+		[]OpenAPIRoute{{Method: http.MethodPost, Path: "/kitcodec.test.UserService/UpdateUser"}},
+		WithOpenAPIJSONOptions(WithInt64AsNumber(Int64OverflowString)),
+	)
+	if got := lookup(t, doc, "components", "schemas", "kitcodec.test.User", "properties", "userId", "type"); !reflect.DeepEqual(got, []any{"integer", "string"}) {
+		t.Errorf("userId type with overflow string = %v", got)
+	}
+}
+
+// TestGenerateJSONSchema_Int64Wrappers 包装类型与 int64 字段一样受 WithInt64AsNumber 影响
+func TestGenerateJSONSchema_Int64Wrappers(t *testing.T) {
+	tests := []struct {
+		desc     protoreflect.MessageDescriptor
+		opts     []OpenAPIOption
+		wantType any
+	}{
+		{(&wrapperspb.Int64Value{}).ProtoReflect().Descriptor(), nil, "string"},
+		{(&wrapperspb.UInt64Value{}).ProtoReflect().Descriptor(), nil, "string"},
+		{(&wrapperspb.Int64Value{}).ProtoReflect().Descriptor(), []OpenAPIOption{WithOpenAPIJSONOptions(WithInt64AsNumber(Int64OverflowError))}, "integer"},
+		{(&wrapperspb.UInt64Value{}).ProtoReflect().Descriptor(), []OpenAPIOption{WithOpenAPIJSONOptions(WithInt64AsNumber(Int64OverflowError))}, "integer"},
+		{(&wrapperspb.Int64Value{}).ProtoReflect().Descriptor(), []OpenAPIOption{WithOpenAPIJSONOptions(WithInt64AsNumber(Int64OverflowString))}, []any{"integer", "string"}},
+	}
+	for _, tt := range tests {
+		data, err := GenerateJSONSchema(tt.desc, tt.opts...)
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		var doc map[string]any
+		if err = json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if !reflect.DeepEqual(doc["type"], tt.wantType) {
+			t.Errorf("%s: type = %v, want %v", tt.desc.FullName(), doc["type"], tt.wantType)
+		}
+	}
+}
+
+func TestGenerateOpenAPI_QueryParameters(t *testing.T) {
+	doc := generateTestOpenAPI(t, []OpenAPIRoute{{Method: http.MethodGet, Path: "/kitcodec.test.UserService/GetUser"}})
+	parameters := lookup(t, doc, "paths", "/kitcodec.test.UserService/GetUser", "get", "parameters").([]any)
+	var names []string
+	byName := make(map[string]any)
+	for _, parameter := range parameters {
+		name := lookup(t, parameter, "name").(string)
+		names = append(names, name)
+		byName[name] = parameter
+		if in := lookup(t, parameter, "in"); in != "query" {
+			t.Errorf("%s in = %v", name, in)
+		}
+	}
+	// profiles 为 repeated 消息, 无法作为查询参数描述
+	want := []string{"userId", "userName", "status", "active", "scores", "profile", "createdAt", "balance", "friendIds", "counters", "extra", "readMask"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("parameters = %v, want %v", names, want)
+	}
+	if got := lookup(t, byName["scores"], "explode"); got != true {
+		t.Errorf("scores explode = %v", got)
+	}
+	for _, name := range []string{"profile", "counters"} {
+		if got := lookup(t, byName[name], "style"); got != "deepObject" {
+			t.Errorf("%s style = %v", name, got)
+		}
+	}
+	if got := lookup(t, doc, "paths", "/kitcodec.test.UserService/GetUser", "get", "requestBody"); got != nil {
+		t.Errorf("GET operation has request body: %v", got)
+	}
+}
+
+func TestGenerateJSONSchema_ValidateRules(t *testing.T) {
+	data, err := GenerateJSONSchema(testDescriptor(t, "kitcodec.test.Signup"))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := doc["$ref"]; got != "#/$defs/kitcodec.test.Signup" {
+		t.Fatalf("$ref = %v", got)
+	}
+	signup := lookup(t, doc, "$defs", "kitcodec.test.Signup")
+	if got := lookup(t, signup, "required"); !reflect.DeepEqual(got, []any{"email"}) {
+		t.Errorf("required = %v", got)
+	}
+	properties := lookup(t, signup, "properties")
+	tests := []struct {
+		keys []string
+		want any
+	}{
+		{[]string{"email", "format"}, "email"},
+		{[]string{"password", "minLength"}, 8.0},
+		{[]string{"password", "maxLength"}, 64.0},
+		{[]string{"password", "pattern"}, "^[A-Za-z0-9]+$"},
+		{[]string{"age", "minimum"}, 18.0},
+		{[]string{"age", "exclusiveMaximum"}, 150.0},
+		{[]string{"status", "enum"}, []any{"STATUS_ACTIVE", "STATUS_BANNED"}},
+		{[]string{"tags", "minItems"}, 1.0},
+		{[]string{"tags", "maxItems"}, 5.0},
+		{[]string{"tags", "uniqueItems"}, true},
+		{[]string{"tags", "items", "minLength"}, 2.0},
+		{[]string{"plan", "enum"}, []any{"free", "pro"}},
+		// gt > lt 表示区间之外, 不输出边界
+		{[]string{"score", "exclusiveMinimum"}, nil},
+		{[]string{"score", "exclusiveMaximum"}, nil},
+		{[]string{"limit", "maximum"}, 100.0},
+		{[]string{"note", "minLength"}, nil},
+		// 64 位整数默认为字符串, 边界写入 description
+		{[]string{"quota", "exclusiveMinimum"}, nil},
+		{[]string{"quota", "maximum"}, nil},
+		{[]string{"quota", "description"}, "range: > 0, <= 1000"},
+		{[]string{"tier", "enum"}, []any{"1", "2"}},
+	}
+	for _, tt := range tests {
+		if got := lookup(t, properties, tt.keys...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v = %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+
+	data, err = GenerateJSONSchema(testDescriptor(t, "kitcodec.test.Signup"), WithOpenAPIJSONOptions(WithUseEnumNumbers()))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got := lookup(t, doc, "$defs", "kitcodec.test.Signup", "properties", "status", "enum"); !reflect.DeepEqual(got, []any{1.0, 2.0}) {
+		t.Errorf("status enum with numbers = %v", got)
+	}
+
+	data, err = GenerateJSONSchema(testDescriptor(t, "kitcodec.test.Signup"), WithOpenAPIJSONOptions(WithInt64AsNumber(Int64OverflowError)))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	doc = nil
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	quota := lookup(t, doc, "$defs", "kitcodec.test.Signup", "properties", "quota")
+	if got := lookup(t, quota, "exclusiveMinimum"); got != 0.0 {
+		t.Errorf("quota exclusiveMinimum with int64 as number = %v", got)
+	}
+	if got := lookup(t, quota, "maximum"); got != 1000.0 {
+		t.Errorf("quota maximum with int64 as number = %v", got)
+	}
+	if got := lookup(t, quota, "description"); got != nil {
+		t.Errorf("quota description with int64 as number = %v", got)
+	}
+	if got := lookup(t, doc, "$defs", "kitcodec.test.Signup", "properties", "tier", "enum"); !reflect.DeepEqual(got, []any{1.0, 2.0}) {
+		t.Errorf("tier enum with int64 as number = %v", got)
+	}
+}
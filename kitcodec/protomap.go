@@ -0,0 +1,573 @@
+package kitcodec
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "strconv"
+    "time"
+
+    "google.golang.org/protobuf/encoding/protojson"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// protomap 在 proto 消息与通用的 map[string]any/[]any/标量 结构之间转换,
+// 供 msgpack 与 CBOR 等非 protobuf 的二进制编解码器使用.
+// 字段名使用 json_name, 解码时同时接受 proto 字段名; 枚举使用名称;
+// Timestamp 使用各格式原生的时间类型, 其它内置类型沿用 protojson 的表示.
+
+// BinaryCodecOption 配置 msgpack 与 CBOR 编解码器
+type BinaryCodecOption func(*binaryCodecOptions)
+
+type binaryCodecOptions struct {
+    resolver Resolver
+}
+
+// WithBinaryResolver 使用自定义的类型解析器处理 Any, 默认为 protoregistry.GlobalTypes
+func WithBinaryResolver(resolver Resolver) BinaryCodecOption {
+    return func(o *binaryCodecOptions) {
+        o.resolver = resolver
+    }
+}
+
+func (o *binaryCodecOptions) apply(opts []BinaryCodecOption) {
+    for _, opt := range opts {
+        opt(o)
+    }
+}
+
+func (o *binaryCodecOptions) mapper() *protoMapper {
+    resolver := o.resolver
+    if resolver == nil {
+        resolver = protoregistry.GlobalTypes
+    }
+    return &protoMapper{resolver: resolver}
+}
+
+// protoMapper 按 resolver 解析 Any 的类型, 在 proto 消息与通用结构之间转换
+type protoMapper struct {
+    resolver Resolver
+}
+
+// messageToValue 将 proto 消息转换为通用结构
+func (p *protoMapper) messageToValue(m protoreflect.Message) (any, error) {
+    desc := m.Descriptor()
+    switch desc.FullName() {
+    case "google.protobuf.Timestamp":
+        fields := desc.Fields()
+        seconds := m.Get(fields.ByName("seconds")).Int()
+        nanos := m.Get(fields.ByName("nanos")).Int()
+        return time.Unix(seconds, nanos).UTC(), nil
+    case "google.protobuf.Any":
+        return p.anyToValue(m)
+    }
+    if isWrapper(desc.FullName()) {
+        fd := desc.Fields().ByName("value")
+        return scalarToValue(fd, m.Get(fd)), nil
+    }
+    if isWellKnownJSON(desc.FullName()) {
+        return wellKnownToValue(m)
+    }
+    out := make(map[string]any, desc.Fields().Len())
+    var err error
+    m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+        var value any
+        value, err = p.fieldToValue(fd, v)
+        if err != nil {
+            return false
+        }
+        out[fieldKey(fd)] = value
+        return true
+    })
+    if err != nil {
+        return nil, err
+    }
+    return out, nil
+}
+
+func fieldKey(fd protoreflect.FieldDescriptor) string {
+    if fd.IsExtension() {
+        return "[" + string(fd.FullName()) + "]"
+    }
+    return fd.JSONName()
+}
+
+func (p *protoMapper) fieldToValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (any, error) {
+    switch {
+    case fd.IsList():
+        list := v.List()
+        out := make([]any, list.Len())
+        for i := range out {
+            value, err := p.singleToValue(fd, list.Get(i))
+            if err != nil {
+                return nil, err
+            }
+            out[i] = value
+        }
+        return out, nil
+    case fd.IsMap():
+        out := make(map[string]any, v.Map().Len())
+        var err error
+        v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+            var value any
+            value, err = p.singleToValue(fd.MapValue(), v)
+            if err != nil {
+                return false
+            }
+            out[k.String()] = value
+            return true
+        })
+        if err != nil {
+            return nil, err
+        }
+        return out, nil
+    default:
+        return p.singleToValue(fd, v)
+    }
+}
+
+func (p *protoMapper) singleToValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (any, error) {
+    if fd.Message() != nil {
+        return p.messageToValue(v.Message())
+    }
+    return scalarToValue(fd, v), nil
+}
+
+func scalarToValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+    switch fd.Kind() {
+    case protoreflect.BoolKind:
+        return v.Bool()
+    case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+        protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+        return v.Int()
+    case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+        protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+        return v.Uint()
+    case protoreflect.FloatKind:
+        return float32(v.Float())
+    case protoreflect.DoubleKind:
+        return v.Float()
+    case protoreflect.StringKind:
+        return v.String()
+    case protoreflect.BytesKind:
+        return v.Bytes()
+    case protoreflect.EnumKind:
+        if fd.Enum().FullName() == "google.protobuf.NullValue" {
+            return nil
+        }
+        if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+            return string(ev.Name())
+        }
+        return int64(v.Enum())
+    }
+    return nil
+}
+
+func (p *protoMapper) anyToValue(m protoreflect.Message) (any, error) {
+    fields := m.Descriptor().Fields()
+    typeURL := m.Get(fields.ByName("type_url")).String()
+    if typeURL == "" {
+        return map[string]any{}, nil
+    }
+    mt, err := p.resolver.FindMessageByURL(typeURL)
+    if err != nil {
+        return nil, fmt.Errorf("resolve any type %q: %w", typeURL, err)
+    }
+    inner := mt.New()
+    if err = proto.Unmarshal(m.Get(fields.ByName("value")).Bytes(), inner.Interface()); err != nil {
+        return nil, err
+    }
+    value, err := p.messageToValue(inner)
+    if err != nil {
+        return nil, err
+    }
+    if object, ok := value.(map[string]any); ok && !isWellKnownJSON(inner.Descriptor().FullName()) {
+        object["@type"] = typeURL
+        return object, nil
+    }
+    return map[string]any{"@type": typeURL, "value": value}, nil
+}
+
+// wellKnownToValue 使用 protojson 的表示处理 Duration/FieldMask/Struct 等内置类型
+func wellKnownToValue(m protoreflect.Message) (any, error) {
+    data, err := protojson.Marshal(m.Interface())
+    if err != nil {
+        return nil, err
+    }
+    var value any
+    if err = json.Unmarshal(data, &value); err != nil {
+        return nil, err
+    }
+    return value, nil
+}
+
+// valueToMessage 将通用结构写入 proto 消息
+func (p *protoMapper) valueToMessage(value any, m protoreflect.Message) error {
+    desc := m.Descriptor()
+    switch desc.FullName() {
+    case "google.protobuf.Timestamp":
+        t, err := toTime(value)
+        if err != nil {
+            return err
+        }
+        fields := desc.Fields()
+        m.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+        m.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+        return nil
+    case "google.protobuf.Any":
+        return p.valueToAny(value, m)
+    }
+    if isWrapper(desc.FullName()) {
+        fd := desc.Fields().ByName("value")
+        v, err := valueToScalar(fd, value)
+        if err != nil {
+            return err
+        }
+        m.Set(fd, v)
+        return nil
+    }
+    if isWellKnownJSON(desc.FullName()) {
+        data, err := json.Marshal(value)
+        if err != nil {
+            return err
+        }
+        return protojson.Unmarshal(data, m.Interface())
+    }
+    object, ok := value.(map[string]any)
+    if !ok {
+        return fmt.Errorf("%s: expected map, got %T", desc.FullName(), value)
+    }
+    fields := desc.Fields()
+    for key, v := range object {
+        fd := fields.ByJSONName(key)
+        if fd == nil {
+            fd = fields.ByName(protoreflect.Name(key))
+        }
+        // 与 JSON 编解码器一致, 忽略未知字段
+        if fd == nil || v == nil {
+            continue
+        }
+        if err := p.valueToField(fd, v, m); err != nil {
+            return fmt.Errorf("%s: %w", fd.FullName(), err)
+        }
+    }
+    return nil
+}
+
+func (p *protoMapper) valueToField(fd protoreflect.FieldDescriptor, value any, m protoreflect.Message) error {
+    switch {
+    case fd.IsList():
+        items, ok := value.([]any)
+        if !ok {
+            return fmt.Errorf("expected list, got %T", value)
+        }
+        list := m.Mutable(fd).List()
+        for _, item := range items {
+            v, err := p.valueToSingle(fd, item, list.NewElement)
+            if err != nil {
+                return err
+            }
+            list.Append(v)
+        }
+        return nil
+    case fd.IsMap():
+        object, ok := value.(map[string]any)
+        if !ok {
+            return fmt.Errorf("expected map, got %T", value)
+        }
+        mp := m.Mutable(fd).Map()
+        for k, item := range object {
+            key, err := valueToMapKey(fd.MapKey(), k)
+            if err != nil {
+                return err
+            }
+            v, err := p.valueToSingle(fd.MapValue(), item, mp.NewValue)
+            if err != nil {
+                return err
+            }
+            mp.Set(key, v)
+        }
+        return nil
+    default:
+        v, err := p.valueToSingle(fd, value, func() protoreflect.Value { return m.NewField(fd) })
+        if err != nil {
+            return err
+        }
+        m.Set(fd, v)
+        return nil
+    }
+}
+
+func (p *protoMapper) valueToSingle(fd protoreflect.FieldDescriptor, value any, newMessage func() protoreflect.Value) (protoreflect.Value, error) {
+    if fd.Message() != nil {
+        v := newMessage()
+        if err := p.valueToMessage(value, v.Message()); err != nil {
+            return protoreflect.Value{}, err
+        }
+        return v, nil
+    }
+    return valueToScalar(fd, value)
+}
+
+func valueToScalar(fd protoreflect.FieldDescriptor, value any) (protoreflect.Value, error) {
+    switch fd.Kind() {
+    case protoreflect.BoolKind:
+        b, ok := value.(bool)
+        if !ok {
+            return protoreflect.Value{}, fmt.Errorf("expected bool, got %T", value)
+        }
+        return protoreflect.ValueOfBool(b), nil
+    case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+        n, err := toInt64(value)
+        if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+            return protoreflect.Value{}, fmt.Errorf("invalid int32 value %v", value)
+        }
+        return protoreflect.ValueOfInt32(int32(n)), nil
+    case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+        n, err := toInt64(value)
+        if err != nil {
+            return protoreflect.Value{}, err
+        }
+        return protoreflect.ValueOfInt64(n), nil
+    case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+        n, err := toUint64(value)
+        if err != nil || n > math.MaxUint32 {
+            return protoreflect.Value{}, fmt.Errorf("invalid uint32 value %v", value)
+        }
+        return protoreflect.ValueOfUint32(uint32(n)), nil
+    case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+        n, err := toUint64(value)
+        if err != nil {
+            return protoreflect.Value{}, err
+        }
+        return protoreflect.ValueOfUint64(n), nil
+    case protoreflect.FloatKind:
+        f, err := toFloat64(value)
+        if err != nil {
+            return protoreflect.Value{}, err
+        }
+        return protoreflect.ValueOfFloat32(float32(f)), nil
+    case protoreflect.DoubleKind:
+        f, err := toFloat64(value)
+        if err != nil {
+            return protoreflect.Value{}, err
+        }
+        return protoreflect.ValueOfFloat64(f), nil
+    case protoreflect.StringKind:
+        s, ok := value.(string)
+        if !ok {
+            return protoreflect.Value{}, fmt.Errorf("expected string, got %T", value)
+        }
+        return protoreflect.ValueOfString(s), nil
+    case protoreflect.BytesKind:
+        switch b := value.(type) {
+        case []byte:
+            return protoreflect.ValueOfBytes(b), nil
+        case string:
+            return protoreflect.ValueOfBytes([]byte(b)), nil
+        }
+        return protoreflect.Value{}, fmt.Errorf("expected bytes, got %T", value)
+    case protoreflect.EnumKind:
+        if s, ok := value.(string); ok {
+            ev := fd.Enum().Values().ByName(protoreflect.Name(s))
+            if ev == nil {
+                return protoreflect.Value{}, fmt.Errorf("invalid enum value %q for %s", s, fd.Enum().FullName())
+            }
+            return protoreflect.ValueOfEnum(ev.Number()), nil
+        }
+        n, err := toInt64(value)
+        if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+            return protoreflect.Value{}, fmt.Errorf("invalid enum value %v", value)
+        }
+        return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+    }
+    return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
+
+func valueToMapKey(fd protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+    var value any = key
+    switch fd.Kind() {
+    case protoreflect.StringKind:
+    case protoreflect.BoolKind:
+        b, err := strconv.ParseBool(key)
+        if err != nil {
+            return protoreflect.MapKey{}, fmt.Errorf("invalid map key %q", key)
+        }
+        value = b
+    default:
+        value = json.Number(key)
+    }
+    v, err := valueToScalar(fd, value)
+    if err != nil {
+        return protoreflect.MapKey{}, err
+    }
+    return v.MapKey(), nil
+}
+
+func (p *protoMapper) valueToAny(value any, m protoreflect.Message) error {
+    object, ok := value.(map[string]any)
+    if !ok {
+        return fmt.Errorf("google.protobuf.Any: expected map, got %T", value)
+    }
+    typeURL, _ := object["@type"].(string)
+    if typeURL == "" {
+        if len(object) == 0 {
+            return nil
+        }
+        return fmt.Errorf("google.protobuf.Any: missing @type")
+    }
+    mt, err := p.resolver.FindMessageByURL(typeURL)
+    if err != nil {
+        return fmt.Errorf("resolve any type %q: %w", typeURL, err)
+    }
+    inner := mt.New()
+    if isWellKnownJSON(inner.Descriptor().FullName()) {
+        err = p.valueToMessage(object["value"], inner)
+    } else {
+        fields := make(map[string]any, len(object))
+        for k, v := range object {
+            if k != "@type" {
+                fields[k] = v
+            }
+        }
+        err = p.valueToMessage(fields, inner)
+    }
+    if err != nil {
+        return err
+    }
+    data, err := proto.MarshalOptions{Deterministic: true}.Marshal(inner.Interface())
+    if err != nil {
+        return err
+    }
+    fields := m.Descriptor().Fields()
+    m.Set(fields.ByName("type_url"), protoreflect.ValueOfString(typeURL))
+    m.Set(fields.ByName("value"), protoreflect.ValueOfBytes(data))
+    return nil
+}
+
+func isWrapper(name protoreflect.FullName) bool {
+    switch name {
+    case "google.protobuf.BoolValue",
+        "google.protobuf.BytesValue",
+        "google.protobuf.DoubleValue",
+        "google.protobuf.FloatValue",
+        "google.protobuf.Int32Value",
+        "google.protobuf.Int64Value",
+        "google.protobuf.StringValue",
+        "google.protobuf.UInt32Value",
+        "google.protobuf.UInt64Value":
+        return true
+    }
+    return false
+}
+
+func toInt64(value any) (int64, error) {
+    switch n := value.(type) {
+    case int:
+        return int64(n), nil
+    case int8:
+        return int64(n), nil
+    case int16:
+        return int64(n), nil
+    case int32:
+        return int64(n), nil
+    case int64:
+        return n, nil
+    case uint:
+        return toInt64(uint64(n))
+    case uint8:
+        return int64(n), nil
+    case uint16:
+        return int64(n), nil
+    case uint32:
+        return int64(n), nil
+    case uint64:
+        if n > math.MaxInt64 {
+            return 0, fmt.Errorf("integer %d overflows int64", n)
+        }
+        return int64(n), nil
+    case float32:
+        return toInt64(float64(n))
+    case float64:
+        if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+            return 0, fmt.Errorf("invalid integer %v", n)
+        }
+        return int64(n), nil
+    case json.Number:
+        return strconv.ParseInt(string(n), 10, 64)
+    case string:
+        return strconv.ParseInt(n, 10, 64)
+    }
+    return 0, fmt.Errorf("expected integer, got %T", value)
+}
+
+func toUint64(value any) (uint64, error) {
+    switch n := value.(type) {
+    case uint:
+        return uint64(n), nil
+    case uint8:
+        return uint64(n), nil
+    case uint16:
+        return uint64(n), nil
+    case uint32:
+        return uint64(n), nil
+    case uint64:
+        return n, nil
+    case float32:
+        return toUint64(float64(n))
+    case float64:
+        if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 {
+            return 0, fmt.Errorf("invalid unsigned integer %v", n)
+        }
+        return uint64(n), nil
+    case json.Number:
+        return strconv.ParseUint(string(n), 10, 64)
+    case string:
+        return strconv.ParseUint(n, 10, 64)
+    }
+    n, err := toInt64(value)
+    if err != nil {
+        return 0, err
+    }
+    if n < 0 {
+        return 0, fmt.Errorf("invalid unsigned integer %d", n)
+    }
+    return uint64(n), nil
+}
+
+func toFloat64(value any) (float64, error) {
+    switch n := value.(type) {
+    case float32:
+        return float64(n), nil
+    case float64:
+        return n, nil
+    case json.Number:
+        return n.Float64()
+    case string:
+        return strconv.ParseFloat(n, 64)
+    }
+    if n, err := toInt64(value); err == nil {
+        return float64(n), nil
+    }
+    n, err := toUint64(value)
+    if err != nil {
+        return 0, fmt.Errorf("expected number, got %T", value)
+    }
+    return float64(n), nil
+}
+
+func toTime(value any) (time.Time, error) {
+    switch t := value.(type) {
+    case time.Time:
+        return t, nil
+    case string:
+        return time.Parse(time.RFC3339Nano, t)
+    }
+    seconds, err := toInt64(value)
+    if err != nil {
+        return time.Time{}, fmt.Errorf("expected time, got %T", value)
+    }
+    return time.Unix(seconds, 0), nil
+}
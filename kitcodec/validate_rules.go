@@ -0,0 +1,263 @@
+package kitcodec
+
+import (
+    "fmt"
+    "strings"
+
+    "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldRules 读取字段上的 protovalidate 规则, 没有规则或规则被忽略时返回 nil
+func fieldRules(fd protoreflect.FieldDescriptor) *validate.FieldRules {
+    options := fd.Options()
+    if options == nil || !proto.HasExtension(options, validate.E_Field) {
+        return nil
+    }
+    rules, _ := proto.GetExtension(options, validate.E_Field).(*validate.FieldRules)
+    if rules.GetIgnore() == validate.Ignore_IGNORE_ALWAYS {
+        return nil
+    }
+    return rules
+}
+
+// fieldRequired 判断字段是否必填: proto2 required 或 protovalidate 的 required
+func fieldRequired(fd protoreflect.FieldDescriptor) bool {
+    return fd.Cardinality() == protoreflect.Required || fieldRules(fd).GetRequired()
+}
+
+// applyFieldRules 把字段上的 protovalidate 规则转换为 JSON Schema 关键字写入 schema.
+// 只转换能够等价表达的规则, CEL 表达式等其余规则仍只在服务端校验
+func (g *openAPIGenerator) applyFieldRules(fd protoreflect.FieldDescriptor, schema map[string]any) {
+    rules := fieldRules(fd)
+    if rules == nil {
+        return
+    }
+    switch {
+    case fd.IsMap():
+        if mapRules := rules.GetMap(); mapRules != nil {
+            setUint(schema, "minProperties", mapRules.HasMinPairs(), mapRules.GetMinPairs())
+            setUint(schema, "maxProperties", mapRules.HasMaxPairs(), mapRules.GetMaxPairs())
+            if values, ok := schema["additionalProperties"].(map[string]any); ok {
+                g.applyTypeRules(fd.MapValue(), mapRules.GetValues(), values)
+            }
+        }
+    case fd.IsList():
+        if repeatedRules := rules.GetRepeated(); repeatedRules != nil {
+            setUint(schema, "minItems", repeatedRules.HasMinItems(), repeatedRules.GetMinItems())
+            setUint(schema, "maxItems", repeatedRules.HasMaxItems(), repeatedRules.GetMaxItems())
+            if repeatedRules.GetUnique() {
+                schema["uniqueItems"] = true
+            }
+            if items, ok := schema["items"].(map[string]any); ok {
+                g.applyTypeRules(fd, repeatedRules.GetItems(), items)
+            }
+        }
+    default:
+        g.applyTypeRules(fd, rules, schema)
+    }
+}
+
+// applyTypeRules 转换单个值的类型规则, 包装类型使用其内部标量的规则
+func (g *openAPIGenerator) applyTypeRules(fd protoreflect.FieldDescriptor, rules *validate.FieldRules, schema map[string]any) {
+    if rules == nil || schema["$ref"] != nil {
+        return
+    }
+    m := rules.ProtoReflect()
+    typeField := m.WhichOneof(m.Descriptor().Oneofs().ByName("type"))
+    if typeField == nil {
+        return
+    }
+    switch typeField.Name() {
+    case "string":
+        applyStringRules(rules.GetString(), schema)
+    case "enum":
+        if fd.Enum() != nil {
+            g.applyEnumRules(fd.Enum(), rules.GetEnum(), schema)
+        }
+    case "bool":
+        if rules.GetBool().HasConst() {
+            schema["const"] = rules.GetBool().GetConst()
+        }
+    case "float", "double", "int32", "int64", "uint32", "uint64",
+        "sint32", "sint64", "fixed32", "fixed64", "sfixed32", "sfixed64":
+        applyNumberRules(m.Get(typeField).Message(), schema)
+    }
+}
+
+func applyStringRules(rules *validate.StringRules, schema map[string]any) {
+    if rules.HasConst() {
+        schema["const"] = rules.GetConst()
+    }
+    if rules.HasLen() {
+        schema["minLength"] = rules.GetLen()
+        schema["maxLength"] = rules.GetLen()
+    }
+    setUint(schema, "minLength", rules.HasMinLen(), rules.GetMinLen())
+    setUint(schema, "maxLength", rules.HasMaxLen(), rules.GetMaxLen())
+    if rules.HasPattern() {
+        schema["pattern"] = rules.GetPattern()
+    }
+    if in := rules.GetIn(); len(in) > 0 {
+        enum := make([]any, 0, len(in))
+        for _, value := range in {
+            enum = append(enum, value)
+        }
+        schema["enum"] = enum
+    }
+    switch {
+    case rules.GetEmail():
+        schema["format"] = "email"
+    case rules.GetHostname():
+        schema["format"] = "hostname"
+    case rules.GetIpv4():
+        schema["format"] = "ipv4"
+    case rules.GetIpv6():
+        schema["format"] = "ipv6"
+    case rules.GetUri():
+        schema["format"] = "uri"
+    case rules.GetUriRef():
+        schema["format"] = "uri-reference"
+    case rules.GetUuid():
+        schema["format"] = "uuid"
+    }
+}
+
+// applyNumberRules 通过反射读取各数值规则共有的 const/gt/gte/lt/lte/in 字段.
+// gt 大于 lt 表示取值在区间之外, JSON Schema 无法直接表达, 此时不输出边界.
+// 64 位整数默认编码为字符串(type: string), minimum 等关键字对字符串无效, 边界写入 description, const/in 输出为字符串
+func applyNumberRules(rules protoreflect.Message, schema map[string]any) {
+    fields := rules.Descriptor().Fields()
+    get := func(name protoreflect.Name) (protoreflect.Value, bool) {
+        fd := fields.ByName(name)
+        if fd == nil || !rules.Has(fd) {
+            return protoreflect.Value{}, false
+        }
+        return rules.Get(fd), true
+    }
+    stringEncoded := schema["type"] == "string"
+    value := func(v protoreflect.Value) any {
+        if stringEncoded {
+            return fmt.Sprint(v.Interface())
+        }
+        return v.Interface()
+    }
+    if v, ok := get("const"); ok {
+        schema["const"] = value(v)
+    }
+    lowerKeyword, lower, hasLower := "minimum", protoreflect.Value{}, false
+    if lower, hasLower = get("gte"); !hasLower {
+        lowerKeyword = "exclusiveMinimum"
+        lower, hasLower = get("gt")
+    }
+    upperKeyword, upper, hasUpper := "maximum", protoreflect.Value{}, false
+    if upper, hasUpper = get("lte"); !hasUpper {
+        upperKeyword = "exclusiveMaximum"
+        upper, hasUpper = get("lt")
+    }
+    if !hasLower || !hasUpper || compareNumber(lower, upper) <= 0 {
+        var bounds []string
+        if hasLower {
+            bounds = append(bounds, fmt.Sprintf("%s %v", boundOperators[lowerKeyword], lower.Interface()))
+            if !stringEncoded {
+                schema[lowerKeyword] = lower.Interface()
+            }
+        }
+        if hasUpper {
+            bounds = append(bounds, fmt.Sprintf("%s %v", boundOperators[upperKeyword], upper.Interface()))
+            if !stringEncoded {
+                schema[upperKeyword] = upper.Interface()
+            }
+        }
+        if stringEncoded && len(bounds) > 0 {
+            schema["description"] = "range: " + strings.Join(bounds, ", ")
+        }
+    }
+    if in, ok := get("in"); ok && in.List().Len() > 0 {
+        list := in.List()
+        enum := make([]any, 0, list.Len())
+        for i := 0; i < list.Len(); i++ {
+            enum = append(enum, value(list.Get(i)))
+        }
+        schema["enum"] = enum
+    }
+}
+
+// boundOperators 边界关键字对应的比较符, 用于字符串编码的数值的 description
+var boundOperators = map[string]string{
+    "minimum":          ">=",
+    "exclusiveMinimum": ">",
+    "maximum":          "<=",
+    "exclusiveMaximum": "<",
+}
+
+// compareNumber 比较两个同类型的数值规则
+func compareNumber(a, b protoreflect.Value) int {
+    var less, greater bool
+    switch x := a.Interface().(type) {
+    case int32:
+        y := b.Interface().(int32)
+        less, greater = x < y, x > y
+    case int64:
+        y := b.Interface().(int64)
+        less, greater = x < y, x > y
+    case uint32:
+        y := b.Interface().(uint32)
+        less, greater = x < y, x > y
+    case uint64:
+        y := b.Interface().(uint64)
+        less, greater = x < y, x > y
+    case float32:
+        y := b.Interface().(float32)
+        less, greater = x < y, x > y
+    case float64:
+        y := b.Interface().(float64)
+        less, greater = x < y, x > y
+    }
+    switch {
+    case less:
+        return -1
+    case greater:
+        return 1
+    }
+    return 0
+}
+
+// applyEnumRules 按 in/not_in 收窄枚举的取值范围
+func (g *openAPIGenerator) applyEnumRules(desc protoreflect.EnumDescriptor, rules *validate.EnumRules, schema map[string]any) {
+    in := make(map[protoreflect.EnumNumber]bool, len(rules.GetIn()))
+    for _, number := range rules.GetIn() {
+        in[protoreflect.EnumNumber(number)] = true
+    }
+    notIn := make(map[protoreflect.EnumNumber]bool, len(rules.GetNotIn()))
+    for _, number := range rules.GetNotIn() {
+        notIn[protoreflect.EnumNumber(number)] = true
+    }
+    if rules.HasConst() {
+        in = map[protoreflect.EnumNumber]bool{protoreflect.EnumNumber(rules.GetConst()): true}
+    }
+    if len(in) == 0 && len(notIn) == 0 {
+        return
+    }
+    values := desc.Values()
+    enum := make([]any, 0, values.Len())
+    for i := 0; i < values.Len(); i++ {
+        value := values.Get(i)
+        if (len(in) > 0 && !in[value.Number()]) || notIn[value.Number()] {
+            continue
+        }
+        if g.codec.marshal.UseEnumNumbers {
+            enum = append(enum, value.Number())
+        } else {
+            enum = append(enum, string(value.Name()))
+        }
+    }
+    schema["enum"] = enum
+}
+
+func setUint(schema map[string]any, keyword string, has bool, value uint64) {
+    if has {
+        schema[keyword] = value
+    }
+}
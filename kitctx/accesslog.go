@@ -0,0 +1,338 @@
+package kitctx
+
+import (
+    "bytes"
+    "compress/gzip"
+    "encoding/binary"
+    "encoding/json"
+    "io"
+    "math/rand/v2"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/rs/zerolog"
+    "github.com/rs/zerolog/log"
+)
+
+// accessLogBodyLimit 解析 Connect 错误码时最多缓存的响应体字节数
+const accessLogBodyLimit = 4 << 10
+
+const redacted = "[REDACTED]"
+
+// AccessLogOption 配置访问日志中间件
+type AccessLogOption func(*accessLogConfig)
+
+type accessLogConfig struct {
+    logger     *zerolog.Logger
+    sampleRate float64
+    slow       time.Duration
+    headers    []string
+    redact     map[string]bool
+}
+
+// WithAccessLogLogger 设置输出访问日志的 zerolog 日志, 默认 log.Logger
+func WithAccessLogLogger(logger zerolog.Logger) AccessLogOption {
+    return func(c *accessLogConfig) {
+        c.logger = &logger
+    }
+}
+
+// WithAccessLogSampleRate 设置成功请求的采样率(0~1), 默认 1 全部记录;
+// 出错与慢请求不受采样影响, 总是记录
+func WithAccessLogSampleRate(rate float64) AccessLogOption {
+    return func(c *accessLogConfig) {
+        c.sampleRate = rate
+    }
+}
+
+// WithAccessLogSlowThreshold 超过 threshold 的请求以 warn 级别记录并标记 slow
+func WithAccessLogSlowThreshold(threshold time.Duration) AccessLogOption {
+    return func(c *accessLogConfig) {
+        c.slow = threshold
+    }
+}
+
+// WithAccessLogHeaders 额外记录的请求头
+func WithAccessLogHeaders(headers ...string) AccessLogOption {
+    return func(c *accessLogConfig) {
+        c.headers = append(c.headers, headers...)
+    }
+}
+
+// WithAccessLogRedact 脱敏的请求头或查询参数名(不区分大小写), 记录时值替换为 [REDACTED].
+// Authorization 与 Cookie 请求头默认脱敏
+func WithAccessLogRedact(names ...string) AccessLogOption {
+    return func(c *accessLogConfig) {
+        for _, name := range names {
+            c.redact[strings.ToLower(name)] = true
+        }
+    }
+}
+
+// GinMiddlewareAccessLog 使用 zerolog 记录访问日志, 需要放在其他中间件之前, 字段包括:
+// method、route(路由模板)、procedure(Connect 方法)、status、code(Connect 错误码, 含流式响应的 end-stream 错误)、latency、bytes、
+// client_ip(GinMiddlewareClientIp)、subject(JWT sub)、request_id(GinMiddlewareRequestId)
+// 示例:
+//
+//	router.Use(kitctx.GinMiddlewareAccessLog(kitctx.WithAccessLogSlowThreshold(time.Second)))
+func GinMiddlewareAccessLog(opts ...AccessLogOption) gin.HandlerFunc {
+    config := &accessLogConfig{
+        sampleRate: 1,
+        redact:     map[string]bool{"authorization": true, "cookie": true},
+    }
+    for _, opt := range opts {
+        opt(config)
+    }
+    return func(c *gin.Context) {
+        start := time.Now()
+        writer := &accessLogWriter{ResponseWriter: c.Writer}
+        c.Writer = writer
+        c.Next()
+        c.Writer = writer.ResponseWriter
+        config.log(c, writer, time.Since(start))
+    }
+}
+
+func (config *accessLogConfig) log(c *gin.Context, writer *accessLogWriter, latency time.Duration) {
+    status := writer.Status()
+    code := connectCode(writer)
+    slow := config.slow > 0 && latency >= config.slow
+    failed := status >= http.StatusBadRequest || code != ""
+    if !failed && !slow && config.sampleRate < 1 && rand.Float64() >= config.sampleRate {
+        return
+    }
+    logger := config.logger
+    if logger == nil {
+        logger = &log.Logger
+    }
+    var event *zerolog.Event
+    switch {
+    case status >= http.StatusInternalServerError:
+        event = logger.Error()
+    case failed, slow:
+        event = logger.Warn()
+    default:
+        event = logger.Info()
+    }
+    event = event.
+        Str("method", c.Request.Method).
+        Str("path", c.Request.URL.Path).
+        Str("route", c.FullPath()).
+        Int("status", status).
+        Dur("latency", latency).
+        Int("bytes", writer.Size())
+    if procedure := connectProcedure(c.Request.URL.Path); procedure != "" {
+        event = event.Str("procedure", procedure)
+    }
+    if code != "" {
+        event = event.Str("code", code)
+    }
+    if query := config.query(c.Request.URL.Query()); query != "" {
+        event = event.Str("query", query)
+    }
+    ctx := c.Request.Context()
+    if ip, err := GetClientIp(ctx); err == nil {
+        event = event.Str("client_ip", ip)
+    }
+    if id := GetRequestId(ctx); id != "" {
+        event = event.Str("request_id", id)
+    }
+    // claims 由 GinMiddlewareJwtAuth 写入 gin, 或由 JwtAuthInterceptor 写入请求级别的存储
+    claims, _ := jwtClaimsKey.Get(ctx)
+    if value, ok := c.Get(ginJwtClaimsKey); ok {
+        claims, _ = value.(jwt.Claims)
+    }
+    if claims != nil {
+        if subject, _ := claims.GetSubject(); subject != "" {
+            event = event.Str("subject", subject)
+        }
+    }
+    if len(config.headers) > 0 {
+        headers := zerolog.Dict()
+        for _, header := range config.headers {
+            if value := c.GetHeader(header); value != "" {
+                headers = headers.Str(header, config.redactValue(header, value))
+            }
+        }
+        event = event.Dict("headers", headers)
+    }
+    if slow {
+        event = event.Bool("slow", true)
+    }
+    event.Msg("access")
+}
+
+func (config *accessLogConfig) redactValue(name, value string) string {
+    if config.redact[strings.ToLower(name)] {
+        return redacted
+    }
+    return value
+}
+
+// query 返回脱敏后的查询字符串
+func (config *accessLogConfig) query(values url.Values) string {
+    if len(values) == 0 {
+        return ""
+    }
+    for name, list := range values {
+        if config.redact[strings.ToLower(name)] {
+            for i := range list {
+                list[i] = redacted
+            }
+        }
+    }
+    return values.Encode()
+}
+
+// connectProcedure 从 /package.Service/Method 路径中解析 Connect 方法名
+func connectProcedure(path string) string {
+    service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+    if !ok || method == "" || strings.Contains(method, "/") || !strings.Contains(service, ".") {
+        return ""
+    }
+    return "/" + service + "/" + method
+}
+
+// connectCode 从 gRPC 的 Grpc-Status、Connect unary 的 JSON 错误响应体
+// 或 Connect 流式响应的 end-stream 消息中解析错误码
+func connectCode(writer *accessLogWriter) string {
+    header := writer.Header()
+    status := header.Get("Grpc-Status")
+    if status == "" {
+        status = header.Get(http.TrailerPrefix + "Grpc-Status")
+    }
+    if status != "" {
+        number, err := strconv.Atoi(status)
+        if err != nil || number == 0 {
+            return ""
+        }
+        return connect.Code(number).String()
+    }
+    if writer.stream != nil {
+        return writer.stream.code(header.Get("Connect-Content-Encoding"))
+    }
+    if writer.body.Len() == 0 {
+        return ""
+    }
+    var body struct {
+        Code string `json:"code"`
+    }
+    if json.Unmarshal(writer.body.Bytes(), &body) != nil {
+        return ""
+    }
+    return body.Code
+}
+
+// connectEndStreamFlag Connect 流式协议中标记 end-stream 消息的 envelope 标志位
+const connectEndStreamFlag = 0b10
+
+// connectStream 逐段解析 Connect 流式响应的 envelope, 只缓存 end-stream 消息的前 accessLogBodyLimit 字节
+type connectStream struct {
+    header    [5]byte
+    headerLen int
+    remain    uint32
+    end       bool
+    flags     byte
+    payload   bytes.Buffer
+}
+
+func (s *connectStream) write(data []byte) {
+    for len(data) > 0 {
+        if s.remain == 0 {
+            n := copy(s.header[s.headerLen:], data)
+            s.headerLen += n
+            data = data[n:]
+            if s.headerLen < len(s.header) {
+                return
+            }
+            s.headerLen = 0
+            s.flags = s.header[0]
+            s.remain = binary.BigEndian.Uint32(s.header[1:])
+            s.end = s.flags&connectEndStreamFlag != 0
+            if s.end {
+                s.payload.Reset()
+            }
+            continue
+        }
+        n := min(uint32(len(data)), s.remain)
+        if s.end {
+            if remain := accessLogBodyLimit - s.payload.Len(); remain > 0 {
+                s.payload.Write(data[:min(int(n), remain)])
+            }
+        }
+        s.remain -= n
+        data = data[n:]
+    }
+}
+
+// code 解析 end-stream 消息中的错误码, 压缩的消息仅支持 gzip
+func (s *connectStream) code(encoding string) string {
+    if !s.end || s.remain != 0 {
+        return ""
+    }
+    var reader io.Reader = &s.payload
+    if s.flags&0b01 != 0 {
+        if encoding != "gzip" {
+            return ""
+        }
+        gz, err := gzip.NewReader(reader)
+        if err != nil {
+            return ""
+        }
+        defer gz.Close()
+        reader = io.LimitReader(gz, accessLogBodyLimit)
+    }
+    var end struct {
+        Error *struct {
+            Code string `json:"code"`
+        } `json:"error"`
+    }
+    if json.NewDecoder(reader).Decode(&end) != nil || end.Error == nil {
+        return ""
+    }
+    return end.Error.Code
+}
+
+// accessLogWriter 在响应为 JSON 错误时缓存响应体的前 accessLogBodyLimit 字节,
+// 响应为 Connect 流式协议时解析其中的 end-stream 消息
+type accessLogWriter struct {
+    gin.ResponseWriter
+
+    body   bytes.Buffer
+    stream *connectStream
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+    w.capture(data)
+    return w.ResponseWriter.Write(data)
+}
+
+func (w *accessLogWriter) WriteString(s string) (int, error) {
+    w.capture([]byte(s))
+    return w.ResponseWriter.WriteString(s)
+}
+
+func (w *accessLogWriter) capture(data []byte) {
+    if w.stream != nil || strings.HasPrefix(w.Header().Get("Content-Type"), "application/connect+") {
+        if w.stream == nil {
+            w.stream = &connectStream{}
+        }
+        w.stream.write(data)
+        return
+    }
+    if w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+        return
+    }
+    if remain := accessLogBodyLimit - w.body.Len(); remain > 0 {
+        if len(data) > remain {
+            data = data[:remain]
+        }
+        w.body.Write(data)
+    }
+}
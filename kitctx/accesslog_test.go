@@ -0,0 +1,211 @@
+package kitctx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+)
+
+// newAccessLogEngine 创建带访问日志的测试引擎, 返回日志输出
+func newAccessLogEngine(t *testing.T, opts ...AccessLogOption) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	resolver, err := NewClientIpResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := gin.New()
+	engine.Use(
+		GinMiddlewareAccessLog(append([]AccessLogOption{WithAccessLogLogger(zerolog.New(&logs))}, opts...)...),
+		GinMiddlewareClientIp(resolver),
+		GinMiddlewareRequestId(WithRequestIdGenerator(func() string { return "req-1" })),
+	)
+	engine.POST("/user.v1.UserService/*any", func(c *gin.Context) {
+		c.Set(ginJwtClaimsKey, &jwt.RegisteredClaims{Subject: "user-42"})
+		c.Header("Content-Type", "application/json")
+		c.Status(http.StatusNotFound)
+		_, _ = c.Writer.WriteString(`{"code":"not_found","message":"user not found"}`)
+	})
+	engine.GET("/items/:id", func(c *gin.Context) {
+		if c.Query("slow") != "" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	engine.GET("/grpc", func(c *gin.Context) {
+		c.Header("Grpc-Status", "7")
+		c.Status(http.StatusOK)
+	})
+	return engine, &logs
+}
+
+func decodeAccessLog(t *testing.T, logs *bytes.Buffer) map[string]any {
+	t.Helper()
+	if logs.Len() == 0 {
+		return nil
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log %q: %v", logs.String(), err)
+	}
+	logs.Reset()
+	return entry
+}
+
+func TestGinMiddlewareAccessLog_ConnectError(t *testing.T) {
+	engine, logs := newAccessLogEngine(t)
+	req := httptest.NewRequest(http.MethodPost, "/user.v1.UserService/GetUser", strings.NewReader("{}"))
+	req.RemoteAddr = "198.51.100.7:1234"
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	entry := decodeAccessLog(t, logs)
+	want := map[string]any{
+		"level":      "warn",
+		"method":     "POST",
+		"route":      "/user.v1.UserService/*any",
+		"procedure":  "/user.v1.UserService/GetUser",
+		"status":     404.0,
+		"code":       "not_found",
+		"client_ip":  "198.51.100.7",
+		"request_id": "req-1",
+		"subject":    "user-42",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if entry["bytes"].(float64) == 0 {
+		t.Error("bytes not recorded")
+	}
+}
+
+func TestGinMiddlewareAccessLog_GrpcStatus(t *testing.T) {
+	engine, logs := newAccessLogEngine(t)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/grpc", nil))
+	if entry := decodeAccessLog(t, logs); entry["code"] != "permission_denied" {
+		t.Errorf("code = %v", entry["code"])
+	}
+}
+
+// connectEnvelope 按 Connect 流式协议编码一条消息
+func connectEnvelope(flags byte, payload []byte) []byte {
+	envelope := make([]byte, 5, 5+len(payload))
+	envelope[0] = flags
+	binary.BigEndian.PutUint32(envelope[1:], uint32(len(payload)))
+	return append(envelope, payload...)
+}
+
+func TestGinMiddlewareAccessLog_ConnectStream(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte(`{"error":{"code":"unavailable","message":"try later"}}`))
+	_ = gz.Close()
+
+	tests := []struct {
+		name      string
+		encoding  string
+		end       []byte
+		wantLevel string
+		wantCode  any
+	}{
+		{
+			name:      "error",
+			end:       connectEnvelope(0b10, []byte(`{"error":{"code":"permission_denied","message":"denied"}}`)),
+			wantLevel: "warn",
+			wantCode:  "permission_denied",
+		},
+		{
+			name:      "gzip error",
+			encoding:  "gzip",
+			end:       connectEnvelope(0b11, compressed.Bytes()),
+			wantLevel: "warn",
+			wantCode:  "unavailable",
+		},
+		{
+			name:      "success",
+			end:       connectEnvelope(0b10, []byte(`{"metadata":{"k":["v"]}}`)),
+			wantLevel: "info",
+			wantCode:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, logs := newAccessLogEngine(t)
+			engine.POST("/stream.v1.StreamService/Watch", func(c *gin.Context) {
+				c.Header("Content-Type", "application/connect+json")
+				if tt.encoding != "" {
+					c.Header("Connect-Content-Encoding", tt.encoding)
+				}
+				c.Status(http.StatusOK)
+				body := append(connectEnvelope(0, []byte(`{"id":"1"}`)), tt.end...)
+				// 分多次写入, envelope 头跨越写入边界
+				for _, chunk := range [][]byte{body[:3], body[3:14], body[14:]} {
+					_, _ = c.Writer.Write(chunk)
+				}
+			})
+			req := httptest.NewRequest(http.MethodPost, "/stream.v1.StreamService/Watch", strings.NewReader(""))
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+			entry := decodeAccessLog(t, logs)
+			if entry["status"] != 200.0 {
+				t.Errorf("status = %v, want 200", entry["status"])
+			}
+			if entry["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %v", entry["level"], tt.wantLevel)
+			}
+			if entry["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %v", entry["code"], tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGinMiddlewareAccessLog_Sampling(t *testing.T) {
+	engine, logs := newAccessLogEngine(t, WithAccessLogSampleRate(0), WithAccessLogSlowThreshold(10*time.Millisecond))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	if entry := decodeAccessLog(t, logs); entry != nil {
+		t.Errorf("sampled-out request logged: %v", entry)
+	}
+	// 慢请求与出错请求不受采样影响
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1?slow=1", nil))
+	if entry := decodeAccessLog(t, logs); entry["slow"] != true || entry["level"] != "warn" {
+		t.Errorf("slow request entry = %v", entry)
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/user.v1.UserService/GetUser", nil))
+	if entry := decodeAccessLog(t, logs); entry == nil {
+		t.Error("failed request not logged")
+	}
+}
+
+func TestGinMiddlewareAccessLog_Redact(t *testing.T) {
+	engine, logs := newAccessLogEngine(t,
+		WithAccessLogHeaders("Authorization", "X-Tenant", "X-Api-Key"),
+		WithAccessLogRedact("x-api-key", "token"),
+	)
+	req := httptest.NewRequest(http.MethodGet, "/items/1?token=secret&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Api-Key", "secret")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	entry := decodeAccessLog(t, logs)
+	if entry["route"] != "/items/:id" {
+		t.Errorf("route = %v", entry["route"])
+	}
+	headers := entry["headers"].(map[string]any)
+	if headers["Authorization"] != redacted || headers["X-Api-Key"] != redacted || headers["X-Tenant"] != "acme" {
+		t.Errorf("headers = %v", headers)
+	}
+	if query := entry["query"].(string); strings.Contains(query, "secret") || !strings.Contains(query, "page=2") {
+		t.Errorf("query = %q", query)
+	}
+}
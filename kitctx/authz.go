@@ -0,0 +1,245 @@
+package kitctx
+
+import (
+    "context"
+    "strings"
+    "sync"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ReasonMissingPermissions 权限不足时 ErrorInfo.Reason 的值, ErrorInfo.Metadata["missing"] 为逗号分隔的缺少的权限
+const ReasonMissingPermissions = "MISSING_PERMISSIONS"
+
+// Authorizable 支持授权的 claims, 在自定义 claims 上实现
+type Authorizable interface {
+    Roles() []string
+    Permissions() []string
+}
+
+// MatchPermission 判断已授予的权限 granted 是否覆盖 required. 权限以 : 分层, 如 "user:profile:read":
+//   - 上层权限覆盖下层, "user" 覆盖 "user:profile:read"
+//   - * 匹配任意一层, "user:*:read" 覆盖 "user:profile:read"; 单独的 "*" 覆盖所有权限
+func MatchPermission(granted, required string) bool {
+    if granted == "" {
+        return false
+    }
+    grantedParts := strings.Split(granted, ":")
+    requiredParts := strings.Split(required, ":")
+    if len(grantedParts) > len(requiredParts) {
+        return false
+    }
+    for i, part := range grantedParts {
+        if part != "*" && part != requiredParts[i] {
+            return false
+        }
+    }
+    return true
+}
+
+// AuthorizerOption 配置 Authorizer
+type AuthorizerOption func(*Authorizer)
+
+// WithRolePermissions 声明角色拥有的权限, claims 的 Roles() 包含该角色时视为拥有这些权限
+func WithRolePermissions(role string, permissions ...string) AuthorizerOption {
+    return func(a *Authorizer) {
+        a.roles[role] = append(a.roles[role], permissions...)
+    }
+}
+
+// WithProcedurePermissions 声明调用 procedure 需要的权限, 匹配规则同 WithJwtSkip;
+// 多条规则匹配同一 procedure 时需要满足全部规则
+func WithProcedurePermissions(procedure string, permissions ...string) AuthorizerOption {
+    return func(a *Authorizer) {
+        a.procedures = append(a.procedures, procedurePermissions{procedure: procedure, permissions: permissions})
+    }
+}
+
+// WithPermissionOption 从 proto 方法选项中读取需要的权限, ext 为 string 或 repeated string 类型的 MethodOptions 扩展
+// 示例:
+//
+//	// extend google.protobuf.MethodOptions { repeated string permissions = 50001; }
+//	// rpc DeleteUser(DeleteUserRequest) returns (DeleteUserResponse) { option (authz.v1.permissions) = "user:delete"; }
+//	kitctx.NewAuthorizer(kitctx.WithPermissionOption(authzv1.E_Permissions))
+func WithPermissionOption(ext protoreflect.ExtensionType) AuthorizerOption {
+    return func(a *Authorizer) {
+        a.option = ext
+    }
+}
+
+type procedurePermissions struct {
+    procedure   string
+    permissions []string
+}
+
+// Authorizer 按 claims 的角色与权限授权, 同时实现 connect.Interceptor, 应放在 JwtAuthInterceptor 之后
+// 示例:
+//
+//	authorizer := kitctx.NewAuthorizer(
+//	    kitctx.WithRolePermissions("admin", "*"),
+//	    kitctx.WithRolePermissions("editor", "post", "user:read"),
+//	    kitctx.WithProcedurePermissions("/post.v1.PostService/", "post:read"),
+//	)
+//	connect.WithInterceptors(kitctx.NewJwtAuthInterceptor[*types.Claims](), authorizer)
+//	router.POST("/posts", kitctx.GinMiddlewareRequirePermissions(authorizer, "post:write"), handler)
+type Authorizer struct {
+    roles      map[string][]string
+    procedures []procedurePermissions
+    option     protoreflect.ExtensionType
+
+    // optionCache procedure 对应的方法选项中声明的权限
+    optionCache sync.Map
+}
+
+var _ connect.Interceptor = (*Authorizer)(nil)
+
+// NewAuthorizer 创建授权器
+func NewAuthorizer(opts ...AuthorizerOption) *Authorizer {
+    a := &Authorizer{roles: make(map[string][]string)}
+    for _, opt := range opts {
+        opt(a)
+    }
+    return a
+}
+
+// Check 检查 ctx 中的 claims(见 GetClaims)是否拥有全部 permissions.
+// 没有 claims 时返回 CodeUnauthenticated, 缺少权限时返回带 ErrorInfo 的 CodePermissionDenied
+func (a *Authorizer) Check(ctx context.Context, permissions ...string) *connect.Error {
+    if len(permissions) == 0 {
+        return nil
+    }
+    claims, err := GetClaims[jwt.Claims](ctx)
+    if err != nil {
+        return err
+    }
+    var granted []string
+    if authorizable, ok := claims.(Authorizable); ok {
+        granted = append(granted, authorizable.Permissions()...)
+        for _, role := range authorizable.Roles() {
+            granted = append(granted, a.roles[role]...)
+        }
+    }
+    var missing []string
+    for _, required := range permissions {
+        ok := false
+        for _, permission := range granted {
+            if MatchPermission(permission, required) {
+                ok = true
+                break
+            }
+        }
+        if !ok {
+            missing = append(missing, required)
+        }
+    }
+    if len(missing) == 0 {
+        return nil
+    }
+    return WithErrorInfo(NewPermissionDenied("permission denied"), ReasonMissingPermissions, "", map[string]string{
+        "missing": strings.Join(missing, ","),
+    })
+}
+
+// procedurePermissions 返回调用 procedure 需要的全部权限
+func (a *Authorizer) procedurePermissions(spec connect.Spec) []string {
+    var permissions []string
+    for _, rule := range a.procedures {
+        if matchProcedure([]string{rule.procedure}, spec.Procedure) {
+            permissions = append(permissions, rule.permissions...)
+        }
+    }
+    if a.option != nil {
+        if method, ok := spec.Schema.(protoreflect.MethodDescriptor); ok {
+            permissions = append(permissions, a.optionPermissions(spec.Procedure, method)...)
+        }
+    }
+    return permissions
+}
+
+// optionPermissions 读取方法选项中的权限; 选项未按扩展解析(如动态构建的描述)时重新解析
+func (a *Authorizer) optionPermissions(procedure string, method protoreflect.MethodDescriptor) []string {
+    if cached, ok := a.optionCache.Load(procedure); ok {
+        return cached.([]string)
+    }
+    var permissions []string
+    if options := method.Options(); options != nil {
+        if !proto.HasExtension(options, a.option) {
+            if raw, err := proto.Marshal(options); err == nil {
+                resolver := new(protoregistry.Types)
+                _ = resolver.RegisterExtension(a.option)
+                reparsed := options.ProtoReflect().New().Interface()
+                unmarshal := proto.UnmarshalOptions{Resolver: resolver}
+                if unmarshal.Unmarshal(raw, reparsed) == nil {
+                    options = reparsed
+                }
+            }
+        }
+        var value any
+        if proto.HasExtension(options, a.option) {
+            value = proto.GetExtension(options, a.option)
+        }
+        switch value := value.(type) {
+        case string:
+            if value != "" {
+                permissions = []string{value}
+            }
+        case []string:
+            permissions = value
+        case protoreflect.List:
+            // 动态扩展类型(dynamicpb)返回 protoreflect.List
+            for i := 0; i < value.Len(); i++ {
+                permissions = append(permissions, value.Get(i).String())
+            }
+        }
+    }
+    a.optionCache.Store(procedure, permissions)
+    return permissions
+}
+
+// WrapUnary implements connect.Interceptor.
+func (a *Authorizer) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+        if req.Spec().IsClient {
+            return next(ctx, req)
+        }
+        if err := a.Check(ctx, a.procedurePermissions(req.Spec())...); err != nil {
+            return nil, err
+        }
+        return next(ctx, req)
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (a *Authorizer) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (a *Authorizer) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+        if err := a.Check(ctx, a.procedurePermissions(conn.Spec())...); err != nil {
+            return err
+        }
+        return next(ctx, conn)
+    }
+}
+
+// GinMiddlewareRequirePermissions 要求拥有全部 permissions, 应放在 GinMiddlewareJwtAuth 之后
+// 示例:
+//
+//	admin.Use(kitctx.GinMiddlewareJwtAuth[*types.JwtAdminClaims](), kitctx.GinMiddlewareRequirePermissions(authorizer, "admin"))
+func GinMiddlewareRequirePermissions(authorizer *Authorizer, permissions ...string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if err := authorizer.Check(c, permissions...); err != nil {
+            _ = connect.NewErrorWriter().Write(c.Writer, c.Request, err)
+            c.Abort()
+            return
+        }
+        c.Next()
+    }
+}
@@ -0,0 +1,205 @@
+package kitctx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type authzClaims struct {
+	jwt.RegisteredClaims
+	roles       []string
+	permissions []string
+}
+
+func (c *authzClaims) Roles() []string       { return c.roles }
+func (c *authzClaims) Permissions() []string { return c.permissions }
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"user:read", "user:read", true},
+		{"user", "user:profile:read", true},
+		{"user:*", "user:delete", true},
+		{"user:*:read", "user:profile:read", true},
+		{"user:*:read", "user:profile:write", false},
+		{"*", "post:delete", true},
+		{"user:read", "user", false},
+		{"user:read", "user:readonly", false},
+		{"", "user", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPermission(tt.granted, tt.required); got != tt.want {
+			t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizer_Check(t *testing.T) {
+	authorizer := NewAuthorizer(
+		WithRolePermissions("editor", "post", "user:read"),
+	)
+	ctx := ContextWithClaims(context.Background(), &authzClaims{roles: []string{"editor"}, permissions: []string{"comment:*"}})
+
+	if err := authorizer.Check(ctx, "post:publish", "user:read", "comment:delete"); err != nil {
+		t.Errorf("granted: err = %v", err)
+	}
+	err := authorizer.Check(ctx, "user:read", "user:delete", "billing")
+	if err == nil || err.Code() != connect.CodePermissionDenied {
+		t.Fatalf("denied: err = %v", err)
+	}
+	info, ok := ErrorDetail[*errdetails.ErrorInfo](err)
+	if !ok || info.GetReason() != ReasonMissingPermissions || info.GetMetadata()["missing"] != "user:delete,billing" {
+		t.Errorf("error info = %v", info)
+	}
+	if err = authorizer.Check(context.Background(), "post"); err == nil || err.Code() != connect.CodeUnauthenticated {
+		t.Errorf("without claims: err = %v", err)
+	}
+	if err = authorizer.Check(context.Background()); err != nil {
+		t.Errorf("no requirement: err = %v", err)
+	}
+	// 未实现 Authorizable 的 claims 没有任何权限
+	if err = authorizer.Check(ContextWithClaims(context.Background(), &jwt.RegisteredClaims{}), "post"); err == nil || err.Code() != connect.CodePermissionDenied {
+		t.Errorf("plain claims: err = %v", err)
+	}
+}
+
+// testAuthzFile 构建声明了权限扩展的服务, Delete 方法的选项以未解析的字节保存
+func testAuthzFile(t *testing.T) (protoreflect.ExtensionType, protoreflect.ServiceDescriptor) {
+	t.Helper()
+	deleteOptions := &descriptorpb.MethodOptions{}
+	var raw []byte
+	for _, permission := range []string{"post:delete", "audit"} {
+		raw = protowire.AppendTag(raw, 50001, protowire.BytesType)
+		raw = protowire.AppendString(raw, permission)
+	}
+	deleteOptions.ProtoReflect().SetUnknown(raw)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("kitctx/authz_test.proto"),
+		Package:    proto.String("kitctx.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/descriptor.proto", "google/protobuf/empty.proto"},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("permissions"),
+			Number:   proto.Int32(50001),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Extendee: proto.String(".google.protobuf.MethodOptions"),
+			JsonName: proto.String("permissions"),
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("PostService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Get"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty")},
+				{Name: proto.String("Delete"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty"), Options: deleteOptions},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dynamicpb.NewExtensionType(fd.Extensions().Get(0)), fd.Services().Get(0)
+}
+
+func TestAuthorizer_Interceptor(t *testing.T) {
+	ext, service := testAuthzFile(t)
+	authorizer := NewAuthorizer(
+		WithPermissionOption(ext),
+		WithProcedurePermissions("/kitctx.test.PostService/", "post:read"),
+		WithRolePermissions("admin", "*"),
+		WithRolePermissions("reader", "post:read"),
+	)
+	// 按 X-Role 请求头模拟 JwtAuthInterceptor 写入的 claims
+	claimsInterceptor := connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if role := req.Header().Get("X-Role"); role != "" {
+				ctx = ContextWithClaims(ctx, &authzClaims{roles: []string{role}})
+			}
+			return next(ctx, req)
+		}
+	})
+	mux := http.NewServeMux()
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		procedure := "/" + string(service.FullName()) + "/" + string(method.Name())
+		mux.Handle(procedure, connect.NewUnaryHandler(
+			procedure,
+			func(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+				return connect.NewResponse(&emptypb.Empty{}), nil
+			},
+			connect.WithSchema(method),
+			connect.WithInterceptors(claimsInterceptor, authorizer),
+		))
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		role   string
+		code   connect.Code
+	}{
+		{"Get", "reader", 0},
+		{"Get", "", connect.CodeUnauthenticated},
+		{"Get", "guest", connect.CodePermissionDenied},
+		{"Delete", "reader", connect.CodePermissionDenied},
+		{"Delete", "admin", 0},
+	}
+	for _, tt := range tests {
+		client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+"/kitctx.test.PostService/"+tt.method)
+		req := connect.NewRequest(&emptypb.Empty{})
+		if tt.role != "" {
+			req.Header().Set("X-Role", tt.role)
+		}
+		_, err := client.CallUnary(context.Background(), req)
+		if code := connect.CodeOf(err); err != nil && code != tt.code || err == nil && tt.code != 0 {
+			t.Errorf("%s as %q: err = %v, want %v", tt.method, tt.role, err, tt.code)
+		}
+		if tt.method == "Delete" && tt.role == "reader" {
+			if info, _ := ErrorDetail[*errdetails.ErrorInfo](err); info.GetMetadata()["missing"] != "post:delete,audit" {
+				t.Errorf("missing = %v", info.GetMetadata())
+			}
+		}
+	}
+}
+
+func TestGinMiddlewareRequirePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authorizer := NewAuthorizer(WithRolePermissions("admin", "*"))
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if role := c.GetHeader("X-Role"); role != "" {
+			c.Set(ginJwtClaimsKey, &authzClaims{roles: []string{role}})
+		}
+	})
+	engine.GET("/admin", GinMiddlewareRequirePermissions(authorizer, "admin:users"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	for role, want := range map[string]int{"admin": http.StatusNoContent, "guest": http.StatusForbidden, "": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("X-Role", role)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("role %q: status = %d, want %d", role, rec.Code, want)
+		}
+	}
+}
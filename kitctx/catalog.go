@@ -0,0 +1,175 @@
+package kitctx
+
+import (
+    "context"
+    "fmt"
+    "sync"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+    "golang.org/x/text/language"
+)
+
+// HeaderAcceptLanguage 客户端期望的语言
+const HeaderAcceptLanguage = "Accept-Language"
+
+var languageKey = NewKey[[]language.Tag]("language")
+
+// ContextWithLanguage 按 Accept-Language 格式(如 "zh-CN,zh;q=0.9,en;q=0.8")写入客户端期望的语言
+func ContextWithLanguage(ctx context.Context, acceptLanguage string) context.Context {
+    tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+    return languageKey.Set(ctx, tags)
+}
+
+// GetLanguage 返回客户端期望的语言, 按优先级排序.
+// 未通过 ContextWithLanguage 写入时读取 gin 请求的 Accept-Language(ctx 为 *gin.Context 或经过 GinMiddlewareAdapterContext)
+func GetLanguage(ctx context.Context) []language.Tag {
+    if tags, ok := languageKey.Get(ctx); ok {
+        return tags
+    }
+    c, ok := ctx.(*gin.Context)
+    if !ok {
+        c, _ = GetGinContext(unwrapGin(ctx))
+    }
+    if c == nil || c.Request == nil {
+        return nil
+    }
+    tags, _, _ := language.ParseAcceptLanguage(c.GetHeader(HeaderAcceptLanguage))
+    return tags
+}
+
+// ErrorMessage 错误码在某个语言下的提示信息
+type ErrorMessage struct {
+    Lang string
+    Text string
+}
+
+// Msg 创建错误提示信息, lang 为 BCP 47 语言标签, text 可以包含 fmt 占位符
+func Msg(lang, text string) ErrorMessage {
+    return ErrorMessage{Lang: lang, Text: text}
+}
+
+// ErrorDef 错误码目录中的一项, 由 Define 声明, 本身实现 error, 可用于 errors.Is 判断
+type ErrorDef struct {
+    code    connect.Code
+    domain  string
+    reason  string
+    tags    []language.Tag
+    texts   []string
+    matcher language.Matcher
+}
+
+var (
+    errorDefsMu sync.RWMutex
+    errorDefs   = make(map[string]*ErrorDef)
+)
+
+// Define 声明错误码, reason 为机器可读的错误原因, 第一条提示信息为匹配不到语言时的默认值.
+// 同一 reason 重复声明时 panic
+// 示例:
+//
+//	var ErrUserBanned = kitctx.Define(connect.CodePermissionDenied, "USER_BANNED",
+//	    kitctx.Msg("zh-CN", "用户 %s 已被封禁"),
+//	    kitctx.Msg("en", "user %s is banned"),
+//	)
+//
+//	return nil, ErrUserBanned.New(ctx, name)
+func Define(code connect.Code, reason string, msgs ...ErrorMessage) *ErrorDef {
+    return DefineIn("", code, reason, msgs...)
+}
+
+// DefineIn 与 Define 相同, domain 写入 ErrorInfo.Domain, 如 "user.example.com";
+// 不同 domain 下的 reason 互不冲突
+func DefineIn(domain string, code connect.Code, reason string, msgs ...ErrorMessage) *ErrorDef {
+    def := &ErrorDef{code: code, domain: domain, reason: reason}
+    for _, msg := range msgs {
+        def.tags = append(def.tags, language.Make(msg.Lang))
+        def.texts = append(def.texts, msg.Text)
+    }
+    def.matcher = language.NewMatcher(def.tags)
+
+    key := domain + "/" + reason
+    errorDefsMu.Lock()
+    defer errorDefsMu.Unlock()
+    if _, ok := errorDefs[key]; ok {
+        panic(fmt.Sprintf("kitctx: error reason %q already defined in domain %q", reason, domain))
+    }
+    errorDefs[key] = def
+    return def
+}
+
+// LookupErrorDef 按 domain 与 reason 查找已声明的错误码
+func LookupErrorDef(domain, reason string) (*ErrorDef, bool) {
+    errorDefsMu.RLock()
+    defer errorDefsMu.RUnlock()
+    def, ok := errorDefs[domain+"/"+reason]
+    return def, ok
+}
+
+// Error implements error.
+func (d *ErrorDef) Error() string {
+    return d.reason
+}
+
+// Code 返回错误码
+func (d *ErrorDef) Code() connect.Code {
+    return d.code
+}
+
+// Reason 返回错误原因
+func (d *ErrorDef) Reason() string {
+    return d.reason
+}
+
+// Domain 返回错误所属的服务
+func (d *ErrorDef) Domain() string {
+    return d.domain
+}
+
+// Message 按 ctx 中客户端期望的语言(见 GetLanguage)返回提示信息与实际使用的语言;
+// args 不为空时用于格式化提示信息, 没有声明提示信息时返回 reason
+func (d *ErrorDef) Message(ctx context.Context, args ...any) (text string, locale string) {
+    if len(d.texts) == 0 {
+        return d.reason, ""
+    }
+    index := 0
+    if tags := GetLanguage(ctx); len(tags) > 0 {
+        _, index, _ = d.matcher.Match(tags...)
+    }
+    text = d.texts[index]
+    if len(args) > 0 {
+        text = fmt.Sprintf(text, args...)
+    }
+    return text, d.tags[index].String()
+}
+
+// New 创建本地化的错误, 附带 errdetails.ErrorInfo 与 errdetails.LocalizedMessage.
+// 返回的错误满足 errors.Is(err, d)
+func (d *ErrorDef) New(ctx context.Context, args ...any) *connect.Error {
+    return d.NewWithMetadata(ctx, nil, args...)
+}
+
+// NewWithMetadata 与 New 相同, metadata 写入 ErrorInfo.Metadata
+func (d *ErrorDef) NewWithMetadata(ctx context.Context, metadata map[string]string, args ...any) *connect.Error {
+    text, locale := d.Message(ctx, args...)
+    err := connect.NewError(d.code, &definedError{def: d, text: text})
+    WithErrorInfo(err, d.reason, d.domain, metadata)
+    if locale != "" {
+        WithLocalizedMessage(err, locale, text)
+    }
+    return err
+}
+
+// definedError 由 ErrorDef 创建的错误, 提示信息已按语言选择
+type definedError struct {
+    def  *ErrorDef
+    text string
+}
+
+func (e *definedError) Error() string {
+    return e.text
+}
+
+func (e *definedError) Is(target error) bool {
+    return target == e.def
+}
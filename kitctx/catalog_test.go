@@ -0,0 +1,107 @@
+package kitctx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+var errTestBanned = DefineIn("kitctx.test", connect.CodePermissionDenied, "USER_BANNED",
+	Msg("en", "user %s is banned"),
+	Msg("zh-CN", "用户 %s 已被封禁"),
+)
+
+func TestErrorDetailHelpers(t *testing.T) {
+	err := NewBadRequest("invalid user", NewFieldViolation("email", "must be a valid email"))
+	if err.Code() != connect.CodeInvalidArgument {
+		t.Errorf("code = %v", err.Code())
+	}
+	badRequest, ok := ErrorDetail[*errdetails.BadRequest](err)
+	if !ok || len(badRequest.GetFieldViolations()) != 1 || badRequest.GetFieldViolations()[0].GetField() != "email" {
+		t.Errorf("bad request = %v", badRequest)
+	}
+
+	err = WithRetryInfo(NewQuotaFailure("too many requests", NewQuotaViolation("user:42", "daily limit")), 3*time.Second)
+	if err.Code() != connect.CodeResourceExhausted {
+		t.Errorf("code = %v", err.Code())
+	}
+	if quota, ok := ErrorDetail[*errdetails.QuotaFailure](err); !ok || quota.GetViolations()[0].GetSubject() != "user:42" {
+		t.Errorf("quota failure = %v", quota)
+	}
+	if retry, ok := ErrorDetail[*errdetails.RetryInfo](err); !ok || retry.GetRetryDelay().AsDuration() != 3*time.Second {
+		t.Errorf("retry info = %v", retry)
+	}
+	if _, ok := ErrorDetail[*errdetails.ErrorInfo](err); ok {
+		t.Error("unexpected error info")
+	}
+	if _, ok := ErrorDetail[*errdetails.ErrorInfo](errors.New("plain")); ok {
+		t.Error("error info on plain error")
+	}
+}
+
+func TestErrorDef_New(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		wantLocale string
+		wantText   string
+	}{
+		{"default", context.Background(), "en", "user bob is banned"},
+		{"accept language", ContextWithLanguage(context.Background(), "fr;q=0.9,zh;q=0.8"), "zh-CN", "用户 bob 已被封禁"},
+		{"unsupported", ContextWithLanguage(context.Background(), "fr"), "en", "user bob is banned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := errTestBanned.NewWithMetadata(tt.ctx, map[string]string{"user": "bob"}, "bob")
+			if err.Code() != connect.CodePermissionDenied || err.Message() != tt.wantText {
+				t.Errorf("err = %v", err)
+			}
+			if !errors.Is(err, errTestBanned) {
+				t.Error("errors.Is(err, def) = false")
+			}
+			info, ok := ErrorDetail[*errdetails.ErrorInfo](err)
+			if !ok || info.GetReason() != "USER_BANNED" || info.GetDomain() != "kitctx.test" || info.GetMetadata()["user"] != "bob" {
+				t.Errorf("error info = %v", info)
+			}
+			localized, ok := ErrorDetail[*errdetails.LocalizedMessage](err)
+			if !ok || localized.GetLocale() != tt.wantLocale || localized.GetMessage() != tt.wantText {
+				t.Errorf("localized message = %v", localized)
+			}
+		})
+	}
+}
+
+func TestErrorDef_GinLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got *connect.Error
+	engine := gin.New()
+	engine.Use(GinMiddlewareAdapterContext())
+	engine.GET("/", func(c *gin.Context) {
+		got = errTestBanned.New(c.Request.Context(), "bob")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAcceptLanguage, "zh-CN")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if got == nil || got.Message() != "用户 bob 已被封禁" {
+		t.Errorf("err = %v", got)
+	}
+}
+
+func TestDefine_Duplicate(t *testing.T) {
+	if def, ok := LookupErrorDef("kitctx.test", "USER_BANNED"); !ok || def != errTestBanned {
+		t.Errorf("lookup = %v, %v", def, ok)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate reason did not panic")
+		}
+	}()
+	DefineIn("kitctx.test", connect.CodeInternal, "USER_BANNED")
+}
@@ -0,0 +1,266 @@
+package kitctx
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "net/http"
+    "net/netip"
+    "strings"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+)
+
+const clientIpKey = "_omnixkit_client_ip"
+
+const (
+    // HeaderForwarded RFC 7239 Forwarded 请求头
+    HeaderForwarded = "Forwarded"
+    // HeaderXForwardedFor X-Forwarded-For 请求头
+    HeaderXForwardedFor = "X-Forwarded-For"
+    // HeaderXRealIp X-Real-IP 请求头
+    HeaderXRealIp = "X-Real-IP"
+)
+
+// ContextWithClientIp 将客户端IP写入上下文
+func ContextWithClientIp(ctx context.Context, ip string) context.Context {
+    return context.WithValue(ctx, clientIpKey, ip)
+}
+
+// GetClientIp 从context中获取客户端IP地址, 需要先使用 GinMiddlewareClientIp
+// 示例:
+//
+//	ip, err := kitctx.GetClientIp(ctx)
+func GetClientIp(c context.Context) (string, *connect.Error) {
+    ip, ok := c.Value(clientIpKey).(string)
+    if !ok || ip == "" {
+        return "", NewInternal("Client IP Not Found")
+    }
+    return ip, nil
+}
+
+// ClientIpOption 配置 ClientIpResolver
+type ClientIpOption func(*ClientIpResolver) error
+
+// WithTrustedProxies 设置可信代理, 支持 CIDR(10.0.0.0/8) 或单个IP.
+// 只有直连地址属于可信代理时才会读取转发请求头, 默认不信任任何代理
+func WithTrustedProxies(proxies ...string) ClientIpOption {
+    return func(r *ClientIpResolver) error {
+        for _, proxy := range proxies {
+            prefix, err := parsePrefix(proxy)
+            if err != nil {
+                return err
+            }
+            r.trusted = append(r.trusted, prefix)
+        }
+        return nil
+    }
+}
+
+// WithClientIpHeaders 设置依次读取的转发请求头, 默认只读取 X-Forwarded-For.
+// 只有可信代理会覆盖(而不是追加)Forwarded 或 X-Real-IP 时才能加入, 否则客户端可以自行设置这些请求头伪造IP
+func WithClientIpHeaders(headers ...string) ClientIpOption {
+    return func(r *ClientIpResolver) error {
+        r.headers = headers
+        return nil
+    }
+}
+
+// ClientIpResolver 按可信代理解析客户端IP.
+// Forwarded 与 X-Forwarded-For 从右向左跳过可信代理, 第一个不可信的地址即为客户端;
+// 遇到无法解析的地址时放弃该请求头, 防止伪造的值越过可信代理
+type ClientIpResolver struct {
+    trusted []netip.Prefix
+    headers []string
+}
+
+// NewClientIpResolver 创建客户端IP解析器
+// 示例:
+//
+//	resolver, err := kitctx.NewClientIpResolver(kitctx.WithTrustedProxies("10.0.0.0/8", "127.0.0.1"))
+//	// 代理设置 Forwarded 时
+//	resolver, err := kitctx.NewClientIpResolver(
+//	    kitctx.WithTrustedProxies("10.0.0.0/8"),
+//	    kitctx.WithClientIpHeaders(kitctx.HeaderForwarded, kitctx.HeaderXForwardedFor),
+//	)
+func NewClientIpResolver(opts ...ClientIpOption) (*ClientIpResolver, error) {
+    r := &ClientIpResolver{
+        headers: []string{HeaderXForwardedFor},
+    }
+    for _, opt := range opts {
+        if err := opt(r); err != nil {
+            return nil, err
+        }
+    }
+    return r, nil
+}
+
+// Resolve 解析请求的客户端IP, 直连地址无法解析时返回 false
+func (r *ClientIpResolver) Resolve(req *http.Request) (string, bool) {
+    remote, ok := parseAddr(req.RemoteAddr)
+    if !ok {
+        return "", false
+    }
+    if !r.isTrusted(remote) {
+        return remote.String(), true
+    }
+    for _, header := range r.headers {
+        values := req.Header.Values(header)
+        if len(values) == 0 {
+            continue
+        }
+        var addrs []string
+        switch http.CanonicalHeaderKey(header) {
+        case http.CanonicalHeaderKey(HeaderForwarded):
+            addrs = forwardedFor(values)
+        case http.CanonicalHeaderKey(HeaderXRealIp):
+            // 只使用最后一个值, 即可信代理设置的值
+            addrs = values[len(values)-1:]
+        default:
+            addrs = splitList(values)
+        }
+        if ip, ok := r.walk(addrs); ok {
+            return ip.String(), true
+        }
+    }
+    return remote.String(), true
+}
+
+// walk 从右向左跳过可信代理; 全部可信时返回最左侧的地址
+func (r *ClientIpResolver) walk(addrs []string) (netip.Addr, bool) {
+    var client netip.Addr
+    for i := len(addrs) - 1; i >= 0; i-- {
+        ip, ok := parseAddr(addrs[i])
+        if !ok {
+            return netip.Addr{}, false
+        }
+        client = ip
+        if !r.isTrusted(ip) {
+            break
+        }
+    }
+    return client, client.IsValid()
+}
+
+func (r *ClientIpResolver) isTrusted(ip netip.Addr) bool {
+    for _, prefix := range r.trusted {
+        if prefix.Contains(ip) {
+            return true
+        }
+    }
+    return false
+}
+
+// GinMiddlewareClientIp 解析客户端IP并写入请求上下文, connect handler 中可以通过 GetClientIp 读取
+// 示例:
+//
+//	router.Use(kitctx.GinMiddlewareClientIp(resolver))
+func GinMiddlewareClientIp(resolver *ClientIpResolver) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if ip, ok := resolver.Resolve(c.Request); ok {
+            c.Set(clientIpKey, ip)
+            c.Request = c.Request.WithContext(ContextWithClientIp(c.Request.Context(), ip))
+        }
+        c.Next()
+    }
+}
+
+// 从允许的Header列表中设置客户端IP到Context
+//
+// Deprecated: 该中间件信任请求头中的第一个地址, 客户端可以伪造, 请使用 GinMiddlewareClientIp.
+func GinMiddlewareSetClientIp(allowHeaders []string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        clientIP := ""
+        for _, header := range allowHeaders {
+            headerValue := c.GetHeader(header)
+            if headerValue == "" {
+                continue
+            }
+            ip := strings.TrimSpace(strings.Split(headerValue, ",")[0])
+            if net.ParseIP(ip) != nil {
+                clientIP = ip
+                break
+            }
+        }
+        if clientIP == "" {
+            clientIP = c.RemoteIP()
+        }
+        c.Set(clientIpKey, clientIP)
+        c.Request = c.Request.WithContext(ContextWithClientIp(c.Request.Context(), clientIP))
+        c.Next()
+    }
+}
+
+// forwardedFor 提取 RFC 7239 Forwarded 中各节点的 for 参数, 缺少 for 的节点记为空值
+func forwardedFor(values []string) []string {
+    var addrs []string
+    for _, value := range values {
+        for _, element := range splitQuoted(value, ',') {
+            addr := ""
+            for _, pair := range splitQuoted(element, ';') {
+                key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+                if found && strings.EqualFold(key, "for") {
+                    addr = strings.Trim(val, `"`)
+                }
+            }
+            addrs = append(addrs, addr)
+        }
+    }
+    return addrs
+}
+
+// splitQuoted 按 sep 拆分, 忽略引号内的分隔符
+func splitQuoted(value string, sep byte) []string {
+    var parts []string
+    quoted := false
+    start := 0
+    for i := 0; i < len(value); i++ {
+        switch value[i] {
+        case '"':
+            quoted = !quoted
+        case sep:
+            if !quoted {
+                parts = append(parts, value[start:i])
+                start = i + 1
+            }
+        }
+    }
+    return append(parts, value[start:])
+}
+
+func splitList(values []string) []string {
+    var addrs []string
+    for _, value := range values {
+        addrs = append(addrs, strings.Split(value, ",")...)
+    }
+    return addrs
+}
+
+// parseAddr 解析 IP、IP:端口、[IPv6]:端口 形式的地址
+func parseAddr(value string) (netip.Addr, bool) {
+    value = strings.TrimSpace(value)
+    if addrPort, err := netip.ParseAddrPort(value); err == nil {
+        return addrPort.Addr().Unmap(), true
+    }
+    if ip, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")); err == nil {
+        return ip.Unmap(), true
+    }
+    return netip.Addr{}, false
+}
+
+func parsePrefix(value string) (netip.Prefix, error) {
+    if strings.Contains(value, "/") {
+        prefix, err := netip.ParsePrefix(value)
+        if err != nil {
+            return netip.Prefix{}, fmt.Errorf("parse trusted proxy %q: %w", value, err)
+        }
+        return prefix.Masked(), nil
+    }
+    ip, err := netip.ParseAddr(value)
+    if err != nil {
+        return netip.Prefix{}, fmt.Errorf("parse trusted proxy %q: %w", value, err)
+    }
+    ip = ip.Unmap()
+    return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
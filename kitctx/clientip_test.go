@@ -0,0 +1,174 @@
+package kitctx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIpResolver_Resolve(t *testing.T) {
+	allHeaders := []string{HeaderForwarded, HeaderXForwardedFor, HeaderXRealIp}
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		// order 读取的请求头, 为空时使用默认值
+		order []string
+		want  string
+	}{
+		{
+			name:    "untrusted remote ignores headers",
+			remote:  "203.0.113.9:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:   "trusted remote without headers",
+			remote: "10.0.0.2:4000",
+			want:   "10.0.0.2",
+		},
+		{
+			name:    "x-forwarded-for skips trusted hops right to left",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"6.6.6.6, 198.51.100.7, 10.1.1.1"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-forwarded-for spread over several header lines",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"6.6.6.6", "198.51.100.7", "192.168.1.1"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "all hops trusted returns leftmost",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"10.9.9.9, 10.1.1.1"}},
+			want:    "10.9.9.9",
+		},
+		{
+			name:    "garbage in x-forwarded-for falls back to remote",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4, not-an-ip"}},
+			want:    "10.0.0.2",
+		},
+		{
+			name:   "forwarded takes precedence",
+			remote: "10.0.0.2:4000",
+			order:  allHeaders,
+			headers: map[string][]string{
+				"Forwarded":       {`for=198.51.100.7;proto=https, for="[2001:db8::1]:4711";by=10.1.1.1`, "for=10.1.1.1"},
+				"X-Forwarded-For": {"6.6.6.6"},
+			},
+			want: "2001:db8::1",
+		},
+		{
+			name:    "forwarded with unknown node falls back to next header",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"Forwarded": {"for=unknown"}, "X-Forwarded-For": {"198.51.100.7"}},
+			order:   allHeaders,
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-real-ip",
+			remote:  "[::1]:4000",
+			headers: map[string][]string{"X-Real-Ip": {"198.51.100.7"}},
+			order:   allHeaders,
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-real-ip sent twice uses the proxy value",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Real-Ip": {"1.2.3.4", "198.51.100.7"}},
+			order:   allHeaders,
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "x-real-ip with comma is not walked like x-forwarded-for",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Real-Ip": {"1.2.3.4, 10.1.1.1"}},
+			order:   []string{HeaderXRealIp},
+			want:    "10.0.0.2",
+		},
+		{
+			name:   "spoofed forwarded ignored by default",
+			remote: "10.0.0.2:4000",
+			headers: map[string][]string{
+				"Forwarded":       {"for=1.2.3.4"},
+				"X-Real-Ip":       {"1.2.3.4"},
+				"X-Forwarded-For": {"1.2.3.4, 198.51.100.7"},
+			},
+			want: "198.51.100.7",
+		},
+		{
+			name:    "x-real-ip ignored by default",
+			remote:  "10.0.0.2:4000",
+			headers: map[string][]string{"X-Real-Ip": {"1.2.3.4"}},
+			want:    "10.0.0.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []ClientIpOption{WithTrustedProxies("10.0.0.0/8", "192.168.1.1", "::1")}
+			if tt.order != nil {
+				opts = append(opts, WithClientIpHeaders(tt.order...))
+			}
+			resolver, err := NewClientIpResolver(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for key, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			got, ok := resolver.Resolve(req)
+			if !ok || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientIpResolver_InvalidProxy(t *testing.T) {
+	if _, err := NewClientIpResolver(WithTrustedProxies("10.0.0.0/33")); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+	if _, err := NewClientIpResolver(WithTrustedProxies("proxy.local")); err == nil {
+		t.Error("expected error for hostname")
+	}
+}
+
+func TestGinMiddlewareClientIp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resolver, err := NewClientIpResolver(WithTrustedProxies("127.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	engine := gin.New()
+	engine.Use(GinMiddlewareClientIp(resolver))
+	engine.GET("/", func(c *gin.Context) {
+		// connect handler 只能拿到 Request.Context()
+		ip, err := GetClientIp(c.Request.Context())
+		if err != nil {
+			t.Errorf("GetClientIp: %v", err)
+		}
+		got = ip
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "198.51.100.7" {
+		t.Errorf("client ip = %q", got)
+	}
+
+	if _, err := GetClientIp(context.Background()); err == nil {
+		t.Error("expected error without middleware")
+	}
+}
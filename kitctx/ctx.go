@@ -5,21 +5,23 @@ import (
     "context"
     "encoding/json"
     "io"
-    "net"
     "net/http"
-    "net/url"
     "strings"
 
     "connectrpc.com/connect"
     "github.com/gin-gonic/gin"
+    "github.com/qwenode/omnixkit/kitcodec"
+    "google.golang.org/protobuf/reflect/protoreflect"
+    "google.golang.org/protobuf/reflect/protoregistry"
 )
 
 const ginContextKey = "_omnixkit_context"
 
-// 让rpc能够访问gin的上下文, 需要在gin的中间件里使用这个中间件
+// 让rpc能够访问gin的上下文, 需要在gin的中间件里使用这个中间件.
+// 同时安装请求级别的存储, 供 Key.Set/Key.Get 使用
 func GinMiddlewareAdapterContext() gin.HandlerFunc {
     return func(c *gin.Context) {
-        ctx := context.WithValue(c.Request.Context(), ginContextKey, c)
+        ctx := WithBag(context.WithValue(c.Request.Context(), ginContextKey, c))
         c.Request = c.Request.WithContext(ctx)
         c.Next()
     }
@@ -34,62 +36,64 @@ func GetGinContext(ctx context.Context) (*gin.Context, *connect.Error) {
     return g, nil
 }
 
-const clientIpKey = "_omnixkit_client_ip"
+// Connect GET 协议的查询参数
+const (
+    queryMessage     = "message"
+    queryEncoding    = "encoding"
+    queryBase64      = "base64"
+    queryCompression = "compression"
+    queryConnect     = "connect"
+)
 
-// 从context中获取客户端IP地址
-func GetClientIp(c context.Context) string {
-    value := c.Value(clientIpKey)
-    if value == nil {
-        return "1.1.1.1"
-    }
-    return value.(string)
+// MethodGetOption 配置 GinMiddlewareAdapterMethodGet
+type MethodGetOption func(*methodGetConfig)
+
+type methodGetConfig struct {
+    files kitcodec.DescriptorResolver
 }
 
-// 从允许的Header列表中设置客户端IP到Context
-func GinMiddlewareSetClientIp(allowHeaders []string) gin.HandlerFunc {
-    return func(c *gin.Context) {
-        clientIP := ""
-        for _, header := range allowHeaders {
-            headerValue := c.GetHeader(header)
-            if headerValue == "" {
-                continue
-            }
-            ip := strings.TrimSpace(strings.Split(headerValue, ",")[0])
-            if net.ParseIP(ip) != nil {
-                clientIP = ip
-                break
-            }
-        }
-        if clientIP == "" {
-            clientIP = c.RemoteIP()
-        }
-        c.Set(clientIpKey, clientIP)
-        c.Next()
+// WithMethodGetFiles 使用自定义的描述注册表查找请求消息, 默认为 protoregistry.GlobalFiles
+func WithMethodGetFiles(files kitcodec.DescriptorResolver) MethodGetOption {
+    return func(c *methodGetConfig) {
+        c.files = files
     }
 }
 
-func GinMiddlewareAdapterMethodGet() gin.HandlerFunc {
+// GinMiddlewareAdapterMethodGet 将普通的 GET 查询参数转换为 Connect GET 请求(?message=...&encoding=json).
+//   - 支持 a.b=1、a[b]=1 形式的嵌套字段, ids=1&ids=2、ids[]=1、ids[0]=1 形式的数组, items[0].name=x 形式的对象数组
+//   - 能在注册表中找到请求消息时, 按字段描述决定数组与 map, 转换标量类型, 并忽略不属于消息的参数
+//   - 已经带有 message 参数时视为标准 Connect GET 请求, base64、compression 等参数保持不变
+//   - 除 message/encoding 以及由本中间件生成消息时失效的 base64/compression 外, 其余查询参数原样保留
+//   - 只在缺少 encoding 参数时补充 encoding=json
+func GinMiddlewareAdapterMethodGet(opts ...MethodGetOption) gin.HandlerFunc {
+    config := &methodGetConfig{files: protoregistry.GlobalFiles}
+    for _, opt := range opts {
+        opt(config)
+    }
     return func(c *gin.Context) {
         if c.Request.Method == http.MethodGet {
-            if c.Query("message") == "" {
-                queryMap := make(map[string]any)
-                for key, values := range c.Request.URL.Query() {
-                    if key == "encoding" || key == "message" {
-                        continue
-                    }
-                    if len(values) == 1 {
-                        queryMap[key] = values[0]
-                    } else {
-                        queryMap[key] = values
+            query := c.Request.URL.Query()
+            if query.Get(queryMessage) == "" {
+                desc := lookupInputDescriptor(config.files, c.Request.URL.Path)
+                messageJSON, _ := json.Marshal(queryToMessage(c.Request.URL.RawQuery, desc))
+                // 查询参数都是字符串, 能找到请求消息的描述时按字段类型转换
+                if desc != nil {
+                    coerced, err := kitcodec.CoerceJSON(desc, messageJSON)
+                    if err != nil {
+                        _ = connect.NewErrorWriter().Write(c.Writer, c.Request, NewInvalidArgumentErr(err))
+                        c.Abort()
+                        return
                     }
+                    messageJSON = coerced
                 }
-                messageJSON, _ := json.Marshal(queryMap)
-                newQuery := url.Values{}
-                newQuery.Set("message", string(messageJSON))
-                c.Request.URL.RawQuery = newQuery.Encode()
-            }
-            if c.Query("encoding") != "json" {
-                c.Request.URL.RawQuery += "&encoding=json"
+                for _, key := range []string{queryMessage, queryEncoding, queryBase64, queryCompression} {
+                    query.Del(key)
+                }
+                query.Set(queryMessage, string(messageJSON))
+                query.Set(queryEncoding, "json")
+                c.Request.URL.RawQuery = query.Encode()
+            } else if !query.Has(queryEncoding) {
+                c.Request.URL.RawQuery += "&" + queryEncoding + "=json"
             }
             c.Request.Body = io.NopCloser(bytes.NewReader([]byte{}))
             c.Request.ContentLength = -1
@@ -97,3 +101,27 @@ func GinMiddlewareAdapterMethodGet() gin.HandlerFunc {
         c.Next()
     }
 }
+
+// lookupInputDescriptor 根据 /package.Service/Method 路径在注册表中查找请求消息的描述
+func lookupInputDescriptor(files kitcodec.DescriptorResolver, path string) protoreflect.MessageDescriptor {
+    path = strings.TrimSuffix(path, "/")
+    methodIndex := strings.LastIndexByte(path, '/')
+    if methodIndex <= 0 {
+        return nil
+    }
+    serviceIndex := strings.LastIndexByte(path[:methodIndex], '/')
+    service := path[serviceIndex+1 : methodIndex]
+    desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+    if err != nil {
+        return nil
+    }
+    serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+    if !ok {
+        return nil
+    }
+    method := serviceDesc.Methods().ByName(protoreflect.Name(path[methodIndex+1:]))
+    if method == nil {
+        return nil
+    }
+    return method.Input()
+}
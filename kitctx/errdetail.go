@@ -0,0 +1,89 @@
+package kitctx
+
+import (
+    "errors"
+    "time"
+
+    "connectrpc.com/connect"
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/protobuf/proto"
+    "google.golang.org/protobuf/types/known/durationpb"
+)
+
+// AddErrorDetail 向错误附加 errdetails 等 proto 消息, 返回同一个错误便于链式调用;
+// err 为 nil 或消息无法序列化时忽略
+func AddErrorDetail(err *connect.Error, detail proto.Message) *connect.Error {
+    if err == nil || detail == nil {
+        return err
+    }
+    if d, detailErr := connect.NewErrorDetail(detail); detailErr == nil {
+        err.AddDetail(d)
+    }
+    return err
+}
+
+// ErrorDetail 从错误中取出第一个类型为 T 的详情, 如 *errdetails.ErrorInfo
+// 示例:
+//
+//	if info, ok := kitctx.ErrorDetail[*errdetails.ErrorInfo](err); ok && info.Reason == "USER_BANNED" {
+//	}
+func ErrorDetail[T proto.Message](err error) (T, bool) {
+    var zero T
+    var connectErr *connect.Error
+    if !errors.As(err, &connectErr) {
+        return zero, false
+    }
+    for _, d := range connectErr.Details() {
+        value, valueErr := d.Value()
+        if valueErr != nil {
+            continue
+        }
+        if typed, ok := value.(T); ok {
+            return typed, true
+        }
+    }
+    return zero, false
+}
+
+// NewFieldViolation 创建 BadRequest 中的字段错误, field 为字段路径, 如 "user.email"
+func NewFieldViolation(field, description string) *errdetails.BadRequest_FieldViolation {
+    return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+// NewBadRequest 创建带 errdetails.BadRequest 的 CodeInvalidArgument 错误
+// 示例:
+//
+//	return nil, kitctx.NewBadRequest("invalid user",
+//	    kitctx.NewFieldViolation("email", "must be a valid email"),
+//	)
+func NewBadRequest(message string, violations ...*errdetails.BadRequest_FieldViolation) *connect.Error {
+    return AddErrorDetail(NewInvalidArgument(message), &errdetails.BadRequest{FieldViolations: violations})
+}
+
+// NewQuotaViolation 创建 QuotaFailure 中的配额错误, subject 为超出配额的对象, 如 "user:42"
+func NewQuotaViolation(subject, description string) *errdetails.QuotaFailure_Violation {
+    return &errdetails.QuotaFailure_Violation{Subject: subject, Description: description}
+}
+
+// NewQuotaFailure 创建带 errdetails.QuotaFailure 的 CodeResourceExhausted 错误
+func NewQuotaFailure(message string, violations ...*errdetails.QuotaFailure_Violation) *connect.Error {
+    return AddErrorDetail(NewResourceExhausted(message), &errdetails.QuotaFailure{Violations: violations})
+}
+
+// WithErrorInfo 附加 errdetails.ErrorInfo, reason 为机器可读的错误原因(如 "USER_BANNED"), domain 为错误所属的服务
+func WithErrorInfo(err *connect.Error, reason, domain string, metadata map[string]string) *connect.Error {
+    return AddErrorDetail(err, &errdetails.ErrorInfo{Reason: reason, Domain: domain, Metadata: metadata})
+}
+
+// WithRetryInfo 附加 errdetails.RetryInfo, 告知客户端多久之后重试
+// 示例:
+//
+//	return nil, kitctx.WithRetryInfo(kitctx.NewUnavailable("busy"), 3*time.Second)
+func WithRetryInfo(err *connect.Error, delay time.Duration) *connect.Error {
+    return AddErrorDetail(err, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+}
+
+// WithLocalizedMessage 附加 errdetails.LocalizedMessage, locale 为 BCP 47 语言标签, 如 "zh-CN"
+func WithLocalizedMessage(err *connect.Error, locale, message string) *connect.Error {
+    return AddErrorDetail(err, &errdetails.LocalizedMessage{Locale: locale, Message: message})
+}
@@ -0,0 +1,216 @@
+package kitctx
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "reflect"
+    "sync"
+
+    "connectrpc.com/connect"
+    "github.com/qwenode/omnixkit/kitjwt"
+)
+
+// ErrorMapper 将普通错误转换为 Connect 错误, 不处理时返回 nil
+type ErrorMapper func(ctx context.Context, err error) *connect.Error
+
+var (
+    errorMappersMu sync.RWMutex
+    errorMappers   []ErrorMapper
+)
+
+// RegisterErrorMapper 注册全局的错误转换, 后注册的优先, 均优先于内置转换; 应在启动时调用
+// 示例:
+//
+//	kitctx.RegisterErrorMapper(kitctx.MapErrorIs(repo.ErrUserNotFound, connect.CodeNotFound, "user not found"))
+func RegisterErrorMapper(mappers ...ErrorMapper) {
+    errorMappersMu.Lock()
+    defer errorMappersMu.Unlock()
+    errorMappers = append(append([]ErrorMapper{}, mappers...), errorMappers...)
+}
+
+// MapErrorIs 创建 errors.Is(err, target) 时转换为 code 的错误转换, 客户端只能看到 message
+func MapErrorIs(target error, code connect.Code, message string) ErrorMapper {
+    return func(_ context.Context, err error) *connect.Error {
+        if errors.Is(err, target) {
+            return connect.NewError(code, errors.New(message))
+        }
+        return nil
+    }
+}
+
+// MapErrorDef 创建 errors.Is(err, target) 时转换为错误码目录中本地化错误的错误转换, 见 Define
+func MapErrorDef(target error, def *ErrorDef) ErrorMapper {
+    return func(ctx context.Context, err error) *connect.Error {
+        if errors.Is(err, target) {
+            return def.New(ctx)
+        }
+        return nil
+    }
+}
+
+// builtinErrorMappers 内置的错误转换, 在所有自定义转换之后执行
+var builtinErrorMappers = []ErrorMapper{
+    MapErrorIs(context.Canceled, connect.CodeCanceled, "request canceled"),
+    MapErrorIs(context.DeadlineExceeded, connect.CodeDeadlineExceeded, "deadline exceeded"),
+    MapErrorIs(sql.ErrNoRows, connect.CodeNotFound, "not found"),
+    func(_ context.Context, err error) *connect.Error {
+        if IsUniqueViolation(err) {
+            return NewAlreadyExists("already exists")
+        }
+        return nil
+    },
+    func(_ context.Context, err error) *connect.Error {
+        switch {
+        case kitjwt.IsInvalidToken(err) || kitjwt.IsUnexpectedSigningMethod(err):
+            return NewUnauthenticated("token invalid")
+        case kitjwt.IsRefreshTokenReused(err):
+            return NewUnauthenticated("refresh token reused")
+        case kitjwt.IsInvalidRefreshToken(err):
+            return NewUnauthenticated("refresh token invalid")
+        }
+        return nil
+    },
+}
+
+// 唯一约束冲突的错误码
+const (
+    sqlStateUniqueViolation = "23505" // PostgreSQL 等遵循 SQLSTATE 的数据库
+    mysqlErrDupEntry        = 1062    // MySQL ER_DUP_ENTRY
+)
+
+// IsUniqueViolation 判断错误链中是否有唯一约束冲突, 不依赖具体的数据库驱动:
+//   - 实现 SQLState() string 且返回 23505 的错误, 如 pgconn.PgError、pq.Error
+//   - 带有 Number 字段且值为 1062 的错误, 如 mysql.MySQLError
+func IsUniqueViolation(err error) bool {
+    return walkErrors(err, func(err error) bool {
+        if state, ok := err.(interface{ SQLState() string }); ok && state.SQLState() == sqlStateUniqueViolation {
+            return true
+        }
+        value := reflect.ValueOf(err)
+        if value.Kind() == reflect.Pointer {
+            value = value.Elem()
+        }
+        if value.Kind() != reflect.Struct {
+            return false
+        }
+        number := value.FieldByName("Number")
+        return number.IsValid() && number.CanUint() && number.Uint() == mysqlErrDupEntry
+    })
+}
+
+// walkErrors 依次检查错误链(包括 errors.Join 的分支), match 返回 true 时停止
+func walkErrors(err error, match func(error) bool) bool {
+    for err != nil {
+        if match(err) {
+            return true
+        }
+        switch wrapped := err.(type) {
+        case interface{ Unwrap() error }:
+            err = wrapped.Unwrap()
+        case interface{ Unwrap() []error }:
+            for _, e := range wrapped.Unwrap() {
+                if walkErrors(e, match) {
+                    return true
+                }
+            }
+            return false
+        default:
+            return false
+        }
+    }
+    return false
+}
+
+// MapError 将 handler 返回的错误转换为 Connect 错误:
+//   - nil 返回 nil, 已经是 *connect.Error 的原样返回
+//   - 依次尝试 extra、RegisterErrorMapper 注册的以及内置的转换(context、sql.ErrNoRows、唯一约束冲突、kitjwt)
+//   - 都不匹配时记录日志并返回不含细节的 CodeInternal 错误
+func MapError(ctx context.Context, err error, extra ...ErrorMapper) *connect.Error {
+    if err == nil {
+        return nil
+    }
+    var connectErr *connect.Error
+    if errors.As(err, &connectErr) {
+        return connectErr
+    }
+    for _, mapper := range extra {
+        if mapped := mapper(ctx, err); mapped != nil {
+            return mapped
+        }
+    }
+    errorMappersMu.RLock()
+    mappers := errorMappers
+    errorMappersMu.RUnlock()
+    for _, mapper := range mappers {
+        if mapped := mapper(ctx, err); mapped != nil {
+            return mapped
+        }
+    }
+    for _, mapper := range builtinErrorMappers {
+        if mapped := mapper(ctx, err); mapped != nil {
+            return mapped
+        }
+    }
+    Logger(ctx).Error().Err(err).Msg("internal error")
+    return NewInternal("internal error")
+}
+
+// ErrorMapOption 配置错误转换拦截器
+type ErrorMapOption func(*ErrorMapInterceptor)
+
+// WithErrorMappers 添加只对该拦截器生效的错误转换, 优先于全局注册的转换
+func WithErrorMappers(mappers ...ErrorMapper) ErrorMapOption {
+    return func(i *ErrorMapInterceptor) {
+        i.mappers = append(i.mappers, mappers...)
+    }
+}
+
+// ErrorMapInterceptor 将 handler 返回的普通错误转换为 Connect 错误, 见 MapError
+type ErrorMapInterceptor struct {
+    mappers []ErrorMapper
+}
+
+var _ connect.Interceptor = (*ErrorMapInterceptor)(nil)
+
+// NewErrorMapInterceptor 创建错误转换拦截器, 应放在 RequestIdInterceptor 之后, 以便日志带有请求ID
+// 示例:
+//
+//	kitrouter.WithInterceptors(connect.WithInterceptors(
+//	    kitctx.NewRecoveryInterceptor(),
+//	    kitctx.NewRequestIdInterceptor(),
+//	    kitctx.NewErrorMapInterceptor(),
+//	))
+func NewErrorMapInterceptor(opts ...ErrorMapOption) *ErrorMapInterceptor {
+    i := &ErrorMapInterceptor{}
+    for _, opt := range opts {
+        opt(i)
+    }
+    return i
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *ErrorMapInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+        res, err := next(ctx, req)
+        if err == nil || req.Spec().IsClient {
+            return res, err
+        }
+        return res, MapError(ctx, err, i.mappers...)
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *ErrorMapInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *ErrorMapInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+        if err := next(ctx, conn); err != nil {
+            return MapError(ctx, err, i.mappers...)
+        }
+        return nil
+    }
+}
@@ -0,0 +1,157 @@
+package kitctx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/qwenode/omnixkit/kitjwt"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// pgError 模拟 pgconn.PgError
+type pgError struct{ code string }
+
+func (e *pgError) Error() string    { return "duplicate key value violates unique constraint" }
+func (e *pgError) SQLState() string { return e.code }
+
+// mysqlError 模拟 mysql.MySQLError
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+var errTestRepoNotFound = errors.New("repo: user not found")
+
+func TestMapError(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&logs)
+	defer func() { log.Logger = logger }()
+
+	bootstrapTestJwt(t)
+	_, jwtErr := kitjwt.Get[*jwt.RegisteredClaims]().Parse("Bearer not-a-token")
+	refresh := kitjwt.NewRefreshTokenManager(kitjwt.NewMemoryTokenStore())
+	_, _, refreshErr := refresh.Rotate(context.Background(), "unknown")
+	refreshToken, err := refresh.Issue(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = refresh.Rotate(context.Background(), refreshToken); err != nil {
+		t.Fatal(err)
+	}
+	_, _, reusedErr := refresh.Rotate(context.Background(), refreshToken)
+
+	tests := []struct {
+		name string
+		err  error
+		code connect.Code
+	}{
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), connect.CodeCanceled},
+		{"deadline", context.DeadlineExceeded, connect.CodeDeadlineExceeded},
+		{"no rows", fmt.Errorf("find user: %w", sql.ErrNoRows), connect.CodeNotFound},
+		{"postgres unique", fmt.Errorf("insert: %w", &pgError{code: "23505"}), connect.CodeAlreadyExists},
+		{"mysql duplicate", errors.Join(errors.New("insert"), &mysqlError{Number: 1062, Message: "Duplicate entry"}), connect.CodeAlreadyExists},
+		{"postgres other", &pgError{code: "23503"}, connect.CodeInternal},
+		{"mysql other", &mysqlError{Number: 1045}, connect.CodeInternal},
+		{"jwt", jwtErr, connect.CodeUnauthenticated},
+		{"refresh token", fmt.Errorf("refresh: %w", refreshErr), connect.CodeUnauthenticated},
+		{"refresh token reused", reusedErr, connect.CodeUnauthenticated},
+		{"extra mapper", fmt.Errorf("get: %w", errTestRepoNotFound), connect.CodeNotFound},
+		{"connect error kept", NewFailedPrecondition("not ready"), connect.CodeFailedPrecondition},
+		{"unknown", errors.New("dial tcp 10.0.0.1:5432: connection refused"), connect.CodeInternal},
+	}
+	extra := MapErrorIs(errTestRepoNotFound, connect.CodeNotFound, "user not found")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			got := MapError(context.Background(), tt.err, extra)
+			if got.Code() != tt.code {
+				t.Fatalf("code = %v, want %v", got.Code(), tt.code)
+			}
+			if tt.code == connect.CodeInternal {
+				if strings.Contains(got.Message(), "10.0.0.1") || got.Message() != "internal error" {
+					t.Errorf("internal details leaked: %q", got.Message())
+				}
+				if !strings.Contains(logs.String(), tt.err.Error()) {
+					t.Errorf("cause not logged: %s", logs.String())
+				}
+			}
+		})
+	}
+	if got := MapError(context.Background(), nil); got != nil {
+		t.Errorf("MapError(nil) = %v", got)
+	}
+}
+
+var bootstrapTestJwtOnce sync.Once
+
+// bootstrapTestJwt 初始化测试用的 kitjwt 单例
+func bootstrapTestJwt(t *testing.T) {
+	t.Helper()
+	bootstrapTestJwtOnce.Do(func() {
+		key, err := kitjwt.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = kitjwt.Bootstrap(key, func() *jwt.RegisteredClaims { return &jwt.RegisteredClaims{} }); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRegisterErrorMapper(t *testing.T) {
+	saved := errorMappers
+	defer func() { errorMappers = saved }()
+
+	errBanned := errors.New("banned")
+	RegisterErrorMapper(MapErrorDef(errBanned, errTestBanned))
+	got := MapError(ContextWithLanguage(context.Background(), "zh-CN"), fmt.Errorf("login: %w", errBanned))
+	if got.Code() != connect.CodePermissionDenied || !errors.Is(got, errTestBanned) {
+		t.Fatalf("err = %v", got)
+	}
+	if info, ok := ErrorDetail[*errdetails.ErrorInfo](got); !ok || info.GetReason() != "USER_BANNED" {
+		t.Errorf("error info = %v", info)
+	}
+	// 后注册的优先
+	RegisterErrorMapper(MapErrorIs(errBanned, connect.CodeUnauthenticated, "banned"))
+	if got = MapError(context.Background(), errBanned); got.Code() != connect.CodeUnauthenticated {
+		t.Errorf("code = %v", got.Code())
+	}
+}
+
+func TestErrorMapInterceptor(t *testing.T) {
+	const procedure = "/kitctx.test.Service/Get"
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(
+		procedure,
+		func(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+			return nil, fmt.Errorf("load: %w", sql.ErrNoRows)
+		},
+		connect.WithInterceptors(NewErrorMapInterceptor()),
+	))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+procedure)
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("err = %v", err)
+	}
+	if strings.Contains(err.Error(), "sql") {
+		t.Errorf("cause leaked: %v", err)
+	}
+}
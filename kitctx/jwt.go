@@ -2,6 +2,8 @@ package kitctx
 
 import (
     "context"
+    "net/http"
+    "strings"
 
     "connectrpc.com/connect"
     "github.com/gin-gonic/gin"
@@ -11,43 +13,293 @@ import (
 
 const ginJwtClaimsKey = "_omnixkit_jwt"
 
-// GinMiddlewareJwtAuth 创建 JWT 认证中间件
+// HeaderAuthorization 携带 JWT 的请求头
+const HeaderAuthorization = "Authorization"
+
+var jwtClaimsKey = NewKey[jwt.Claims]("jwt_claims")
+
+// ContextWithClaims 将 claims 写入上下文, 之后可以通过 GetClaims 读取
+func ContextWithClaims(ctx context.Context, claims jwt.Claims) context.Context {
+    return jwtClaimsKey.Set(ctx, claims)
+}
+
+// GinMiddlewareJwtAuth 创建 JWT 认证中间件, 使用 kitjwt.Bootstrap 初始化的实例
 // 示例:
 //
 //	router.Use(kitctx.GinMiddlewareJwtAuth[*types.JwtAdminClaims]())
 func GinMiddlewareJwtAuth[T jwt.Claims]() gin.HandlerFunc {
+    return GinMiddlewareJwtAuthNamed[T](kitjwt.DefaultName)
+}
+
+// GinMiddlewareJwtAuthNamed 创建 JWT 认证中间件, 使用 kitjwt.Register 以 name 注册的实例;
+// 实例未注册或 claims 类型不是 T 时返回 Internal 错误
+// 示例:
+//
+//	admin.Use(kitctx.GinMiddlewareJwtAuthNamed[*types.JwtAdminClaims]("admin"))
+//	member.Use(kitctx.GinMiddlewareJwtAuthNamed[*types.JwtMemberClaims]("member"))
+func GinMiddlewareJwtAuthNamed[T jwt.Claims](name string) gin.HandlerFunc {
     return func(c *gin.Context) {
-        tokenHeader := c.GetHeader("Authorization")
-        claims, err := kitjwt.Get[T]().Parse(tokenHeader, jwt.WithExpirationRequired())
+        instance, err := kitjwt.Lookup[T](name)
+        if err != nil {
+            _ = connect.NewErrorWriter().Write(c.Writer, c.Request, NewInternalErr(err))
+            c.Abort()
+            return
+        }
+        tokenHeader := c.GetHeader(HeaderAuthorization)
+        claims, err := instance.Parse(tokenHeader, jwt.WithExpirationRequired())
         if err != nil {
             _ = connect.NewErrorWriter().Write(c.Writer, c.Request, NewUnauthenticatedErr(err))
             c.Abort()
             return
         }
         c.Set(ginJwtClaimsKey, claims)
+        // 同时写入请求的上下文, 未使用 GinMiddlewareAdapterContext 时 rpc 内也能读取
+        ContextWithClaims(c, claims)
         c.Next()
     }
 }
 
-// GetClaims 从 gin context 获取 claims
+// GetClaims 获取 claims, 支持 gin 中间件(GinMiddlewareJwtAuth)与 Connect 拦截器(NewJwtAuthInterceptor)两种方式
 // 示例:
 //
 //	claims := kitctx.GetClaims[*types.JwtAdminClaims](c)
 func GetClaims[T jwt.Claims](c context.Context) (T, *connect.Error) {
-    ginContext, err := GetGinContext(c)
-    if err != nil {
-        var zero T
-        return zero, err
+    var zero T
+    var value any
+    if claims, ok := jwtClaimsKey.Get(c); ok {
+        value = claims
+    } else if ginContext, _ := c.(*gin.Context); ginContext != nil {
+        value, _ = ginContext.Get(ginJwtClaimsKey)
+    } else if ginContext, _ = GetGinContext(c); ginContext != nil {
+        value, _ = ginContext.Get(ginJwtClaimsKey)
     }
-    value, _ := ginContext.Get(ginJwtClaimsKey)
     if value == nil {
-        var zero T
         return zero, NewUnauthenticatedErr(nil)
     }
     claims, ok := value.(T)
     if !ok {
-        var zero T
         return zero, NewUnauthenticatedErr(nil)
     }
     return claims, nil
 }
+
+// JwtAuthOption 配置 JWT 认证拦截器
+type JwtAuthOption func(*jwtAuthConfig)
+
+type jwtAuthConfig struct {
+    name        string
+    skip        []string
+    requireOnly []string
+    parseOpts   []jwt.ParserOption
+}
+
+// WithJwtSkip 跳过认证的 procedure, 如 "/user.v1.UserService/Login";
+// 以 / 结尾时匹配整个服务, 如 "/user.v1.PublicService/"
+func WithJwtSkip(procedures ...string) JwtAuthOption {
+    return func(c *jwtAuthConfig) {
+        c.skip = append(c.skip, procedures...)
+    }
+}
+
+// WithJwtRequireOnly 只有匹配的 procedure 必须认证, 其余 procedure 带有 token 时解析, 没有时放行.
+// 默认所有 procedure 都必须认证; 匹配规则同 WithJwtSkip
+func WithJwtRequireOnly(procedures ...string) JwtAuthOption {
+    return func(c *jwtAuthConfig) {
+        c.requireOnly = append(c.requireOnly, procedures...)
+    }
+}
+
+// WithJwtName 使用 kitjwt.Register 以 name 注册的实例解析 token, 默认为 kitjwt.DefaultName
+func WithJwtName(name string) JwtAuthOption {
+    return func(c *jwtAuthConfig) {
+        c.name = name
+    }
+}
+
+// WithJwtParserOptions 替换解析 token 的选项, 默认为 jwt.WithExpirationRequired()
+func WithJwtParserOptions(opts ...jwt.ParserOption) JwtAuthOption {
+    return func(c *jwtAuthConfig) {
+        c.parseOpts = opts
+    }
+}
+
+func matchProcedure(patterns []string, procedure string) bool {
+    for _, pattern := range patterns {
+        if pattern == procedure || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(procedure, pattern)) {
+            return true
+        }
+    }
+    return false
+}
+
+// JwtAuthInterceptor 解析 Authorization 请求头中的 JWT 并写入上下文, 不依赖 gin
+type JwtAuthInterceptor struct {
+    config *jwtAuthConfig
+    parse  func(token string, opts ...jwt.ParserOption) (jwt.Claims, error)
+}
+
+var _ connect.Interceptor = (*JwtAuthInterceptor)(nil)
+
+// NewJwtAuthInterceptor 创建 JWT 认证拦截器, 默认使用 kitjwt.Bootstrap 初始化的实例解析 token, 其他实例见 WithJwtName
+// 示例:
+//
+//	interceptor := kitctx.NewJwtAuthInterceptor[*types.JwtAdminClaims](
+//	    kitctx.WithJwtSkip("/user.v1.UserService/Login"),
+//	)
+//	mux.Handle(userv1connect.NewUserServiceHandler(svc, connect.WithInterceptors(interceptor)))
+func NewJwtAuthInterceptor[T jwt.Claims](opts ...JwtAuthOption) *JwtAuthInterceptor {
+    config := &jwtAuthConfig{name: kitjwt.DefaultName, parseOpts: []jwt.ParserOption{jwt.WithExpirationRequired()}}
+    for _, opt := range opts {
+        opt(config)
+    }
+    return &JwtAuthInterceptor{
+        config: config,
+        parse: func(token string, opts ...jwt.ParserOption) (jwt.Claims, error) {
+            instance, err := kitjwt.Lookup[T](config.name)
+            if err != nil {
+                return nil, NewInternalErr(err)
+            }
+            return instance.Parse(token, opts...)
+        },
+    }
+}
+
+// authenticate 按规则解析 token, 返回写入 claims 后的上下文
+func (i *JwtAuthInterceptor) authenticate(ctx context.Context, procedure string, header http.Header) (context.Context, error) {
+    if matchProcedure(i.config.skip, procedure) {
+        return ctx, nil
+    }
+    token := header.Get(HeaderAuthorization)
+    required := len(i.config.requireOnly) == 0 || matchProcedure(i.config.requireOnly, procedure)
+    if token == "" && !required {
+        return ctx, nil
+    }
+    claims, err := i.parse(token, i.config.parseOpts...)
+    if connectErr, ok := err.(*connect.Error); ok {
+        return ctx, connectErr
+    }
+    if err != nil {
+        return ctx, NewUnauthenticatedErr(err)
+    }
+    return ContextWithClaims(ctx, claims), nil
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *JwtAuthInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+        if req.Spec().IsClient {
+            return next(ctx, req)
+        }
+        ctx, err := i.authenticate(ctx, req.Spec().Procedure, req.Header())
+        if err != nil {
+            return nil, err
+        }
+        return next(ctx, req)
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *JwtAuthInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *JwtAuthInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+        ctx, err := i.authenticate(ctx, conn.Spec().Procedure, conn.RequestHeader())
+        if err != nil {
+            return err
+        }
+        return next(ctx, conn)
+    }
+}
+
+// TokenProvider 为客户端请求提供 token, 返回空字符串时不设置 Authorization 请求头
+type TokenProvider func(ctx context.Context) (string, error)
+
+// StaticToken 返回固定 token 的 TokenProvider
+func StaticToken(token string) TokenProvider {
+    return func(context.Context) (string, error) {
+        return token, nil
+    }
+}
+
+// JwtClientInterceptor 为客户端请求设置 Authorization 请求头
+type JwtClientInterceptor struct {
+    provider TokenProvider
+}
+
+var _ connect.Interceptor = (*JwtClientInterceptor)(nil)
+
+// NewJwtClientInterceptor 创建客户端 token 拦截器, token 缺少 "Bearer " 前缀时自动补充
+// 示例:
+//
+//	client := userv1connect.NewUserServiceClient(http.DefaultClient, baseURL,
+//	    connect.WithInterceptors(kitctx.NewJwtClientInterceptor(kitctx.StaticToken(token))),
+//	)
+func NewJwtClientInterceptor(provider TokenProvider) *JwtClientInterceptor {
+    return &JwtClientInterceptor{provider: provider}
+}
+
+// authorization 返回 Authorization 请求头的值
+func (i *JwtClientInterceptor) authorization(ctx context.Context) (string, error) {
+    token, err := i.provider(ctx)
+    if err != nil {
+        return "", NewUnauthenticatedErr(err)
+    }
+    if token == "" || (len(token) >= 7 && strings.EqualFold(token[:7], "bearer ")) {
+        return token, nil
+    }
+    return "Bearer " + token, nil
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *JwtClientInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+        if !req.Spec().IsClient {
+            return next(ctx, req)
+        }
+        value, err := i.authorization(ctx)
+        if err != nil {
+            return nil, err
+        }
+        if value != "" {
+            req.Header().Set(HeaderAuthorization, value)
+        }
+        return next(ctx, req)
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *JwtClientInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+        conn := next(ctx, spec)
+        value, err := i.authorization(ctx)
+        if err != nil {
+            return &failedStreamingClientConn{StreamingClientConn: conn, err: err}
+        }
+        if value != "" {
+            conn.RequestHeader().Set(HeaderAuthorization, value)
+        }
+        return conn
+    }
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *JwtClientInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return next
+}
+
+// failedStreamingClientConn 获取 token 失败时, 发送与接收都返回该错误
+type failedStreamingClientConn struct {
+    connect.StreamingClientConn
+    err error
+}
+
+func (c *failedStreamingClientConn) Send(any) error {
+    return c.err
+}
+
+func (c *failedStreamingClientConn) Receive(any) error {
+    return c.err
+}
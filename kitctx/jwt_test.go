@@ -0,0 +1,217 @@
+package kitctx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/qwenode/omnixkit/kitjwt"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func signTestToken(t *testing.T, subject string) string {
+	t.Helper()
+	bootstrapTestJwt(t)
+	token, err := kitjwt.Get[*jwt.RegisteredClaims]().Sign(&jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// newJwtTestServer 启动带 JWT 拦截器的服务, 返回调用指定 procedure 的函数, 结果为 handler 读到的 subject
+func newJwtTestServer(t *testing.T, opts ...JwtAuthOption) func(procedure string, provider TokenProvider) (string, error) {
+	t.Helper()
+	interceptor := NewJwtAuthInterceptor[*jwt.RegisteredClaims](opts...)
+	mux := http.NewServeMux()
+	var subject string
+	for _, procedure := range []string{"/kitctx.test.Service/Get", "/kitctx.test.Service/Login", "/kitctx.test.Public/List"} {
+		mux.Handle(procedure, connect.NewUnaryHandler(
+			procedure,
+			func(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+				subject = ""
+				if claims, err := GetClaims[*jwt.RegisteredClaims](ctx); err == nil {
+					subject = claims.Subject
+				}
+				return connect.NewResponse(&emptypb.Empty{}), nil
+			},
+			connect.WithInterceptors(interceptor),
+		))
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return func(procedure string, provider TokenProvider) (string, error) {
+		var clientOpts []connect.ClientOption
+		if provider != nil {
+			clientOpts = append(clientOpts, connect.WithInterceptors(NewJwtClientInterceptor(provider)))
+		}
+		client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+procedure, clientOpts...)
+		_, err := client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+		return subject, err
+	}
+}
+
+func TestJwtAuthInterceptor(t *testing.T) {
+	token := signTestToken(t, "user-42")
+	call := newJwtTestServer(t, WithJwtSkip("/kitctx.test.Service/Login", "/kitctx.test.Public/"))
+
+	if subject, err := call("/kitctx.test.Service/Get", StaticToken(token)); err != nil || subject != "user-42" {
+		t.Errorf("with token: subject = %q, err = %v", subject, err)
+	}
+	if subject, err := call("/kitctx.test.Service/Get", StaticToken("Bearer "+token)); err != nil || subject != "user-42" {
+		t.Errorf("with bearer token: subject = %q, err = %v", subject, err)
+	}
+	if _, err := call("/kitctx.test.Service/Get", nil); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("without token: err = %v", err)
+	}
+	if _, err := call("/kitctx.test.Service/Get", StaticToken("invalid")); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("invalid token: err = %v", err)
+	}
+	for _, procedure := range []string{"/kitctx.test.Service/Login", "/kitctx.test.Public/List"} {
+		if subject, err := call(procedure, StaticToken(token)); err != nil || subject != "" {
+			t.Errorf("%s skipped: subject = %q, err = %v", procedure, subject, err)
+		}
+	}
+}
+
+func TestJwtAuthInterceptor_RequireOnly(t *testing.T) {
+	token := signTestToken(t, "user-42")
+	call := newJwtTestServer(t, WithJwtRequireOnly("/kitctx.test.Service/Get"))
+
+	if _, err := call("/kitctx.test.Service/Get", nil); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("required without token: err = %v", err)
+	}
+	if subject, err := call("/kitctx.test.Service/Login", nil); err != nil || subject != "" {
+		t.Errorf("optional without token: subject = %q, err = %v", subject, err)
+	}
+	if subject, err := call("/kitctx.test.Service/Login", StaticToken(token)); err != nil || subject != "user-42" {
+		t.Errorf("optional with token: subject = %q, err = %v", subject, err)
+	}
+	if _, err := call("/kitctx.test.Service/Login", StaticToken("invalid")); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("optional with invalid token: err = %v", err)
+	}
+}
+
+func TestJwtClientInterceptor_ProviderError(t *testing.T) {
+	call := newJwtTestServer(t)
+	_, err := call("/kitctx.test.Service/Get", func(context.Context) (string, error) {
+		return "", errors.New("refresh failed")
+	})
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestGetClaims_Gin(t *testing.T) {
+	token := signTestToken(t, "user-42")
+	gin.SetMode(gin.TestMode)
+	for _, adapter := range []bool{false, true} {
+		var fromRequest, fromGin string
+		engine := gin.New()
+		if adapter {
+			engine.Use(GinMiddlewareAdapterContext())
+		}
+		engine.Use(GinMiddlewareJwtAuth[*jwt.RegisteredClaims]())
+		engine.GET("/", func(c *gin.Context) {
+			if claims, err := GetClaims[*jwt.RegisteredClaims](c.Request.Context()); err == nil {
+				fromRequest = claims.Subject
+			}
+			if claims, err := GetClaims[*jwt.RegisteredClaims](c); err == nil {
+				fromGin = claims.Subject
+			}
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAuthorization, "Bearer "+token)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		if fromRequest != "user-42" || fromGin != "user-42" {
+			t.Errorf("adapter=%v: request = %q, gin = %q", adapter, fromRequest, fromGin)
+		}
+	}
+	if _, err := GetClaims[*jwt.RegisteredClaims](context.Background()); err == nil || err.Code() != connect.CodeUnauthenticated {
+		t.Errorf("missing claims: err = %v", err)
+	}
+}
+
+// testMemberClaims 与默认实例 claims 类型不同的实例
+type testMemberClaims struct {
+	jwt.RegisteredClaims
+	Level int `json:"level"`
+}
+
+func TestGinMiddlewareJwtAuthNamed(t *testing.T) {
+	adminToken := signTestToken(t, "admin-1")
+	key, err := kitjwt.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := kitjwt.NewKeyring()
+	if err = keyring.Add("", key); err != nil {
+		t.Fatal(err)
+	}
+	member := kitjwt.New(keyring, func() *testMemberClaims { return &testMemberClaims{} })
+	if err = kitjwt.Register("kitctx.test.member", member); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { kitjwt.Unregister("kitctx.test.member") })
+	memberToken, err := member.Sign(&testMemberClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "member-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Level:            3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/admin", GinMiddlewareJwtAuth[*jwt.RegisteredClaims](), func(c *gin.Context) {
+		claims, _ := GetClaims[*jwt.RegisteredClaims](c)
+		c.String(http.StatusOK, claims.Subject)
+	})
+	engine.GET("/member", GinMiddlewareJwtAuthNamed[*testMemberClaims]("kitctx.test.member"), func(c *gin.Context) {
+		claims, _ := GetClaims[*testMemberClaims](c)
+		c.String(http.StatusOK, "%s:%d", claims.Subject, claims.Level)
+	})
+	engine.GET("/mismatch", GinMiddlewareJwtAuthNamed[*testMemberClaims](kitjwt.DefaultName), func(c *gin.Context) {})
+	serve := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("/admin", adminToken); rec.Code != http.StatusOK || rec.Body.String() != "admin-1" {
+		t.Errorf("admin: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve("/member", memberToken); rec.Code != http.StatusOK || rec.Body.String() != "member-1:3" {
+		t.Errorf("member: %d %s", rec.Code, rec.Body.String())
+	}
+	// 实例之间的 token 不能互用
+	if rec := serve("/member", adminToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("admin token on member route: %d", rec.Code)
+	}
+	if rec := serve("/admin", memberToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("member token on admin route: %d", rec.Code)
+	}
+	// claims 类型不匹配时返回错误而不是 panic
+	if rec := serve("/mismatch", adminToken); rec.Code != http.StatusInternalServerError {
+		t.Errorf("mismatch: %d", rec.Code)
+	}
+}
+
+func TestJwtAuthInterceptor_Name(t *testing.T) {
+	token := signTestToken(t, "user-42")
+	call := newJwtTestServer(t, WithJwtName("kitctx.test.missing"))
+	if _, err := call("/kitctx.test.Service/Get", StaticToken(token)); connect.CodeOf(err) != connect.CodeInternal {
+		t.Errorf("unregistered instance: err = %v", err)
+	}
+}
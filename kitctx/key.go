@@ -0,0 +1,113 @@
+package kitctx
+
+import (
+    "context"
+    "fmt"
+    "sync"
+
+    "github.com/gin-gonic/gin"
+)
+
+type bagContextKey struct{}
+
+// bag 请求级别的可变存储, 让中间件与 handler 之间可以在不替换 context 的情况下传递数据
+type bag struct {
+    mu     sync.RWMutex
+    values map[any]any
+}
+
+// WithBag 在 ctx 中安装请求级别的存储, 已安装时原样返回.
+// GinMiddlewareAdapterContext 会自动安装; 单元测试、Temporal activity 等不经过 gin 的场景可以手动调用
+func WithBag(ctx context.Context) context.Context {
+    if lookupBag(ctx) != nil {
+        return ctx
+    }
+    return context.WithValue(ctx, bagContextKey{}, &bag{values: make(map[any]any)})
+}
+
+func lookupBag(ctx context.Context) *bag {
+    b, _ := unwrapGin(ctx).Value(bagContextKey{}).(*bag)
+    return b
+}
+
+// unwrapGin 传入 *gin.Context 时使用其请求的上下文
+func unwrapGin(ctx context.Context) context.Context {
+    if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+        return c.Request.Context()
+    }
+    return ctx
+}
+
+// Key 带类型的上下文键, 以指针区分, 同名的两个 Key 互不影响
+// 示例:
+//
+//	var UserKey = kitctx.NewKey[*User]("user")
+//
+//	ctx = UserKey.Set(ctx, user)
+//	user, ok := UserKey.Get(ctx)
+type Key[T any] struct {
+    name string
+}
+
+// NewKey 创建上下文键, name 只用于错误信息
+func NewKey[T any](name string) *Key[T] {
+    return &Key[T]{name: name}
+}
+
+// String 返回键名
+func (k *Key[T]) String() string {
+    return k.name
+}
+
+// Set 写入值并返回新的上下文.
+// ctx 中已安装存储(见 WithBag)时直接写入存储并返回原 ctx, 同一请求后续读取都能看到;
+// 否则等同于 context.WithValue, 需要继续使用返回的 ctx.
+// 传入 *gin.Context 且未安装存储时写入其 Request 的上下文
+func (k *Key[T]) Set(ctx context.Context, value T) context.Context {
+    if b := lookupBag(ctx); b != nil {
+        b.mu.Lock()
+        b.values[k] = value
+        b.mu.Unlock()
+        return ctx
+    }
+    if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+        c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), k, value))
+        return c
+    }
+    return context.WithValue(ctx, k, value)
+}
+
+// Get 读取值, 不存在时返回零值与 false
+func (k *Key[T]) Get(ctx context.Context) (T, bool) {
+    ctx = unwrapGin(ctx)
+    if b := lookupBag(ctx); b != nil {
+        b.mu.RLock()
+        value, ok := b.values[k]
+        b.mu.RUnlock()
+        if ok {
+            // T 为接口类型且存入 nil 时断言会失败, 仍视为存在
+            typed, _ := value.(T)
+            return typed, true
+        }
+    }
+    value, ok := ctx.Value(k).(T)
+    return value, ok
+}
+
+// MustGet 读取值, 不存在时 panic
+func (k *Key[T]) MustGet(ctx context.Context) T {
+    value, ok := k.Get(ctx)
+    if !ok {
+        panic(fmt.Sprintf("kitctx: key %q not found in context", k.name))
+    }
+    return value
+}
+
+// Delete 从存储中删除值, 只对 WithBag 安装的存储有效
+func (k *Key[T]) Delete(ctx context.Context) {
+    if b := lookupBag(ctx); b != nil {
+        b.mu.Lock()
+        delete(b.values, k)
+        b.mu.Unlock()
+    }
+}
@@ -0,0 +1,84 @@
+package kitctx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testUser struct {
+	Name string
+}
+
+func TestKey_WithoutBag(t *testing.T) {
+	key := NewKey[int]("count")
+	ctx := context.Background()
+	if _, ok := key.Get(ctx); ok {
+		t.Fatal("unexpected value in empty context")
+	}
+	ctx = key.Set(ctx, 3)
+	if got, ok := key.Get(ctx); !ok || got != 3 {
+		t.Errorf("Get() = %d, %v", got, ok)
+	}
+	// 同名不同实例的键互不影响
+	if _, ok := NewKey[int]("count").Get(ctx); ok {
+		t.Error("distinct keys with the same name collide")
+	}
+}
+
+func TestKey_WithBag(t *testing.T) {
+	key := NewKey[*testUser]("user")
+	ctx := WithBag(context.Background())
+	if WithBag(ctx) != ctx {
+		t.Error("WithBag installed a second bag")
+	}
+	// 存储安装后, 不使用返回值也能在同一请求中读到
+	_ = key.Set(ctx, &testUser{Name: "alice"})
+	if got := key.MustGet(ctx); got.Name != "alice" {
+		t.Errorf("MustGet() = %+v", got)
+	}
+	// 派生出的 context 共享同一个存储
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	_ = key.Set(child, &testUser{Name: "bob"})
+	if got := key.MustGet(ctx); got.Name != "bob" {
+		t.Errorf("parent sees %+v", got)
+	}
+	key.Delete(ctx)
+	if _, ok := key.Get(ctx); ok {
+		t.Error("value still present after Delete")
+	}
+}
+
+func TestKey_MustGetPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet did not panic")
+		}
+	}()
+	NewKey[string]("missing").MustGet(context.Background())
+}
+
+func TestKey_Gin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key := NewKey[string]("tenant")
+	var got string
+	engine := gin.New()
+	engine.Use(GinMiddlewareAdapterContext())
+	engine.Use(func(c *gin.Context) {
+		// 中间件中直接传入 *gin.Context
+		key.Set(c, "acme")
+		c.Next()
+	})
+	engine.GET("/", func(c *gin.Context) {
+		// connect handler 拿到的是 Request.Context()
+		got = key.MustGet(c.Request.Context())
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got != "acme" {
+		t.Errorf("handler saw %q", got)
+	}
+}
@@ -0,0 +1,226 @@
+package kitctx
+
+import (
+    "net/url"
+    "sort"
+    "strconv"
+    "strings"
+
+    "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// connectQueryKeys Connect GET 协议保留的查询参数, 不转换为消息字段
+var connectQueryKeys = map[string]bool{
+    queryMessage:     true,
+    queryEncoding:    true,
+    queryBase64:      true,
+    queryCompression: true,
+    queryConnect:     true,
+}
+
+// queryToMessage 将查询字符串转换为请求消息的 JSON 结构, 数组元素保持参数出现的顺序.
+// desc 为 nil 时按参数形式推断: 重复的参数与 [] 后缀为数组, 全部为数字下标的对象为数组;
+// desc 不为 nil 时按字段描述决定数组与 map, 并忽略不属于消息的参数
+func queryToMessage(rawQuery string, desc protoreflect.MessageDescriptor) map[string]any {
+    root := &queryNode{}
+    for _, pair := range strings.Split(rawQuery, "&") {
+        if pair == "" {
+            continue
+        }
+        key, value, _ := strings.Cut(pair, "=")
+        key, err := url.QueryUnescape(key)
+        if err != nil || connectQueryKeys[key] {
+            continue
+        }
+        if value, err = url.QueryUnescape(value); err != nil {
+            continue
+        }
+        root.insert(splitQueryKey(key), []string{value})
+    }
+    return root.message(desc)
+}
+
+// splitQueryKey 拆分 a.b、a[b]、a[]、a[0].b 形式的参数名, 空字符串表示 [] 追加;
+// 方括号不成对时整个参数名作为一个字段
+func splitQueryKey(key string) []string {
+    var segments []string
+    rest := key
+    for rest != "" {
+        i := strings.IndexAny(rest, ".[")
+        if i < 0 {
+            segments = append(segments, rest)
+            break
+        }
+        if i > 0 {
+            segments = append(segments, rest[:i])
+        }
+        if rest[i] == '.' {
+            rest = rest[i+1:]
+            continue
+        }
+        end := strings.IndexByte(rest[i:], ']')
+        if end < 0 {
+            return []string{key}
+        }
+        segments = append(segments, rest[i+1:i+end])
+        rest = strings.TrimPrefix(rest[i+end+1:], ".")
+    }
+    return segments
+}
+
+// queryNode 查询参数按路径组成的树
+type queryNode struct {
+    values   []string
+    list     bool
+    keys     []string
+    children map[string]*queryNode
+}
+
+func (n *queryNode) child(key string) *queryNode {
+    if n.children == nil {
+        n.children = make(map[string]*queryNode)
+    }
+    child, ok := n.children[key]
+    if !ok {
+        child = &queryNode{}
+        n.children[key] = child
+        n.keys = append(n.keys, key)
+    }
+    return child
+}
+
+func (n *queryNode) insert(segments []string, values []string) {
+    if len(segments) == 0 {
+        n.values = append(n.values, values...)
+        return
+    }
+    if segments[0] == "" {
+        if len(segments) == 1 {
+            n.list = true
+            n.values = append(n.values, values...)
+            return
+        }
+        // items[][name]=a 每个值追加一个新元素
+        for _, value := range values {
+            n.child(strconv.Itoa(len(n.keys))).insert(segments[1:], []string{value})
+        }
+        return
+    }
+    n.child(segments[0]).insert(segments[1:], values)
+}
+
+func (n *queryNode) message(desc protoreflect.MessageDescriptor) map[string]any {
+    out := make(map[string]any, len(n.keys))
+    for _, key := range n.keys {
+        var fd protoreflect.FieldDescriptor
+        if desc != nil {
+            if fd = lookupQueryField(desc, key); fd == nil {
+                continue
+            }
+        }
+        if value := n.children[key].value(fd); value != nil {
+            out[key] = value
+        }
+    }
+    return out
+}
+
+func lookupQueryField(desc protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+    if fd := desc.Fields().ByName(protoreflect.Name(name)); fd != nil {
+        return fd
+    }
+    return desc.Fields().ByJSONName(name)
+}
+
+// value 转换字段的值, fd 为 nil 时按参数形式推断
+func (n *queryNode) value(fd protoreflect.FieldDescriptor) any {
+    switch {
+    case fd == nil:
+        if len(n.children) > 0 {
+            if indexes, ok := n.indexes(); ok {
+                return n.elements(indexes, nil)
+            }
+            return n.message(nil)
+        }
+        if len(n.values) == 1 && !n.list {
+            return n.values[0]
+        }
+        return stringsToAny(n.values)
+    case fd.IsMap():
+        out := make(map[string]any, len(n.keys))
+        for _, key := range n.keys {
+            if value := n.children[key].single(fd.MapValue()); value != nil {
+                out[key] = value
+            }
+        }
+        return out
+    case fd.IsList():
+        if len(n.children) > 0 {
+            indexes, _ := n.indexes()
+            return n.elements(indexes, fd)
+        }
+        return stringsToAny(n.values)
+    }
+    return n.single(fd)
+}
+
+// single 转换单个值, 消息类型有子参数时继续展开, 标量取最后一个值
+func (n *queryNode) single(fd protoreflect.FieldDescriptor) any {
+    if len(n.children) > 0 {
+        if fd.Message() == nil {
+            return nil
+        }
+        return n.message(fd.Message())
+    }
+    if len(n.values) == 0 {
+        return nil
+    }
+    return n.values[len(n.values)-1]
+}
+
+// indexes 返回按数值排序的数字下标, 存在非数字下标时 ok 为 false
+func (n *queryNode) indexes() ([]string, bool) {
+    indexes := make([]string, 0, len(n.keys))
+    ok := true
+    for _, key := range n.keys {
+        if _, err := strconv.Atoi(key); err != nil {
+            ok = false
+            continue
+        }
+        indexes = append(indexes, key)
+    }
+    sort.SliceStable(indexes, func(i, j int) bool {
+        a, _ := strconv.Atoi(indexes[i])
+        b, _ := strconv.Atoi(indexes[j])
+        return a < b
+    })
+    return indexes, ok
+}
+
+func (n *queryNode) elements(indexes []string, fd protoreflect.FieldDescriptor) []any {
+    out := make([]any, 0, len(indexes)+len(n.values))
+    for _, value := range n.values {
+        out = append(out, value)
+    }
+    for _, index := range indexes {
+        child := n.children[index]
+        var value any
+        if fd != nil {
+            value = child.single(fd)
+        } else {
+            value = child.value(nil)
+        }
+        if value != nil {
+            out = append(out, value)
+        }
+    }
+    return out
+}
+
+func stringsToAny(values []string) []any {
+    out := make([]any, len(values))
+    for i, value := range values {
+        out[i] = value
+    }
+    return out
+}
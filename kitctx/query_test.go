@@ -0,0 +1,282 @@
+package kitctx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// testQueryFile 测试用的服务定义
+const testQueryFile = `
+name: "kitctx/query_test.proto"
+package: "kitctx.test"
+syntax: "proto3"
+message_type: {
+  name: "Filter"
+  field: { name: "status" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "status" }
+  field: { name: "tags" number: 2 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" }
+}
+message_type: {
+  name: "Item"
+  field: { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+  field: { name: "qty" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "qty" }
+}
+message_type: {
+  name: "ListRequest"
+  field: { name: "filter" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".kitctx.test.Filter" json_name: "filter" }
+  field: { name: "ids" number: 2 label: LABEL_REPEATED type: TYPE_INT64 json_name: "ids" }
+  field: { name: "items" number: 3 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".kitctx.test.Item" json_name: "items" }
+  field: { name: "labels" number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".kitctx.test.ListRequest.LabelsEntry" json_name: "labels" }
+  field: { name: "active" number: 5 label: LABEL_OPTIONAL type: TYPE_BOOL json_name: "active" }
+  field: { name: "page_size" number: 6 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "pageSize" }
+  nested_type: {
+    name: "LabelsEntry"
+    field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "key" }
+    field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "value" }
+    options: { map_entry: true }
+  }
+}
+service: {
+  name: "ItemService"
+  method: { name: "List" input_type: ".kitctx.test.ListRequest" output_type: ".kitctx.test.ListRequest" }
+}
+`
+
+func testQueryFiles(t *testing.T) *protoregistry.Files {
+	t.Helper()
+	fdp := new(descriptorpb.FileDescriptorProto)
+	if err := prototext.Unmarshal([]byte(testQueryFile), fdp); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := new(protoregistry.Files)
+	if err = files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// serveMethodGet 经过中间件处理后返回改写的查询参数
+func serveMethodGet(t *testing.T, files *protoregistry.Files, target string) url.Values {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var query url.Values
+	engine := gin.New()
+	engine.Use(GinMiddlewareAdapterMethodGet(WithMethodGetFiles(files)))
+	engine.GET("/*any", func(c *gin.Context) {
+		query = c.Request.URL.Query()
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	return query
+}
+
+func TestSplitQueryKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"name", []string{"name"}},
+		{"filter.status", []string{"filter", "status"}},
+		{"filter[status]", []string{"filter", "status"}},
+		{"ids[]", []string{"ids", ""}},
+		{"items[0].name", []string{"items", "0", "name"}},
+		{"items[0][name]", []string{"items", "0", "name"}},
+		{"a.b[c].d", []string{"a", "b", "c", "d"}},
+		{"broken[key", []string{"broken[key"}},
+	}
+	for _, tt := range tests {
+		if got := splitQueryKey(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitQueryKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGinMiddlewareAdapterMethodGet_WithoutDescriptor(t *testing.T) {
+	files := testQueryFiles(t)
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{
+			name:   "flat",
+			target: "/unknown.v1.Service/Get?id=1&name=x",
+			want:   `{"id":"1","name":"x"}`,
+		},
+		{
+			name:   "nested and arrays",
+			target: "/unknown.v1.Service/Get?filter.status=1&filter[kind]=a&ids[]=1&ids[]=2&tags=a&tags=b&one[]=x",
+			want:   `{"filter":{"kind":"a","status":"1"},"ids":["1","2"],"tags":["a","b"],"one":["x"]}`,
+		},
+		{
+			name:   "keeps parameter order",
+			target: "/unknown.v1.Service/Get?tags=b&tags[]=a&tags=c",
+			want:   `{"tags":["b","a","c"]}`,
+		},
+		{
+			name:   "indexed objects",
+			target: "/unknown.v1.Service/Get?items[1].name=b&items[0].name=a&items[10].name=c",
+			want:   `{"items":[{"name":"a"},{"name":"b"},{"name":"c"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := serveMethodGet(t, files, tt.target)
+			var got, want any
+			if err := json.Unmarshal([]byte(query.Get("message")), &got); err != nil {
+				t.Fatalf("message %q: %v", query.Get("message"), err)
+			}
+			_ = json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("message = %s, want %s", query.Get("message"), tt.want)
+			}
+			if query.Get("encoding") != "json" {
+				t.Errorf("encoding = %q", query.Get("encoding"))
+			}
+		})
+	}
+}
+
+func TestGinMiddlewareAdapterMethodGet_WithDescriptor(t *testing.T) {
+	files := testQueryFiles(t)
+	desc, err := files.FindDescriptorByName("kitctx.test.ListRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{
+			name:   "nested message",
+			target: "/kitctx.test.ItemService/List?filter.status=2&filter[tags]=a&filter.tags=b",
+			want:   `filter: { status: 2 tags: "a" tags: "b" }`,
+		},
+		{
+			name:   "single value for repeated field",
+			target: "/kitctx.test.ItemService/List?ids=7",
+			want:   `ids: 7`,
+		},
+		{
+			name:   "repeated keys and brackets",
+			target: "/kitctx.test.ItemService/List?ids=1&ids[]=2&ids[]=3",
+			want:   `ids: [1, 2, 3]`,
+		},
+		{
+			name:   "repeated messages",
+			target: "/kitctx.test.ItemService/List?items[0].name=a&items[0].qty=2&items[1][name]=b",
+			want:   `items: { name: "a" qty: 2 } items: { name: "b" }`,
+		},
+		{
+			name:   "map with numeric looking keys",
+			target: "/kitctx.test.ItemService/List?labels.env=prod&labels[0]=zero",
+			want:   `labels: { key: "env" value: "prod" } labels: { key: "0" value: "zero" }`,
+		},
+		{
+			name:   "scalars, proto names and unknown params",
+			target: "/kitctx.test.ItemService/List?active=1&page_size=20&fields=ids&connect=v1",
+			want:   `active: true page_size: 20`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := serveMethodGet(t, files, tt.target)
+			got := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+			if err := protojson.Unmarshal([]byte(query.Get("message")), got); err != nil {
+				t.Fatalf("message %q: %v", query.Get("message"), err)
+			}
+			want := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+			if err := prototext.Unmarshal([]byte(tt.want), want); err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("message = %s, want %s", query.Get("message"), tt.want)
+			}
+		})
+	}
+}
+
+func TestGinMiddlewareAdapterMethodGet_Query(t *testing.T) {
+	files := testQueryFiles(t)
+	tests := []struct {
+		name   string
+		target string
+		want   url.Values
+	}{
+		{
+			name:   "preserve other params",
+			target: "/kitctx.test.ItemService/List?page_size=1&fields=ids&connect=v1",
+			want: url.Values{
+				"message":   {`{"pageSize":1}`},
+				"encoding":  {"json"},
+				"fields":    {"ids"},
+				"connect":   {"v1"},
+				"page_size": {"1"},
+			},
+		},
+		{
+			// 无法转换为 JSON 数字的值原样保留, 不影响其他字段的转换
+			name:   "value that is not a json number keeps coercion",
+			target: "/kitctx.test.ItemService/List?active=1&page_size=%2B5",
+			want: url.Values{
+				"message":   {`{"active":true,"pageSize":"+5"}`},
+				"encoding":  {"json"},
+				"active":    {"1"},
+				"page_size": {"+5"},
+			},
+		},
+		{
+			name:   "drop base64 and compression when building message",
+			target: "/unknown.v1.Service/Get?x=1&encoding=proto&base64=1&compression=gzip",
+			want: url.Values{
+				"message":  {`{"x":"1"}`},
+				"encoding": {"json"},
+				"x":        {"1"},
+			},
+		},
+		{
+			name:   "connect get request left untouched",
+			target: "/unknown.v1.Service/Get?message=eyJ4IjoxfQ&base64=1&compression=gzip&connect=v1",
+			want: url.Values{
+				"message":     {"eyJ4IjoxfQ"},
+				"base64":      {"1"},
+				"compression": {"gzip"},
+				"connect":     {"v1"},
+				"encoding":    {"json"},
+			},
+		},
+		{
+			name:   "existing encoding kept",
+			target: "/unknown.v1.Service/Get?message=CgE&encoding=proto&base64=1",
+			want: url.Values{
+				"message":  {"CgE"},
+				"encoding": {"proto"},
+				"base64":   {"1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveMethodGet(t, files, tt.target); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("query = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
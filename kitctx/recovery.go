@@ -0,0 +1,149 @@
+package kitctx
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "runtime/debug"
+    "strings"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// PanicReporter 上报 panic 的钩子, 如发送到 Sentry
+type PanicReporter func(ctx context.Context, recovered any, stack []byte)
+
+// RecoveryOption 配置 panic 恢复中间件与拦截器
+type RecoveryOption func(*recoveryConfig)
+
+type recoveryConfig struct {
+    reporter PanicReporter
+    debug    bool
+}
+
+// WithPanicReporter 设置 panic 上报钩子
+func WithPanicReporter(reporter PanicReporter) RecoveryOption {
+    return func(c *recoveryConfig) {
+        c.reporter = reporter
+    }
+}
+
+// WithPanicDebug 为 true 时在错误中附加 errdetails.DebugInfo(panic 信息与调用栈), 只应在开发环境开启
+func WithPanicDebug(debug bool) RecoveryOption {
+    return func(c *recoveryConfig) {
+        c.debug = debug
+    }
+}
+
+func newRecoveryConfig(opts []RecoveryOption) *recoveryConfig {
+    c := &recoveryConfig{}
+    for _, opt := range opts {
+        opt(c)
+    }
+    return c
+}
+
+// recover 记录日志、调用上报钩子并转换为 CodeInternal
+func (c *recoveryConfig) recover(ctx context.Context, recovered any) *connect.Error {
+    stack := debug.Stack()
+    Logger(ctx).Error().
+        Str("panic", fmt.Sprint(recovered)).
+        Bytes("stack", stack).
+        Msg("panic recovered")
+    if c.reporter != nil {
+        c.reporter(ctx, recovered, stack)
+    }
+    err := NewInternal("internal error")
+    if c.debug {
+        detail, detailErr := connect.NewErrorDetail(&errdetails.DebugInfo{
+            StackEntries: strings.Split(strings.TrimSpace(string(stack)), "\n"),
+            Detail:       fmt.Sprint(recovered),
+        })
+        if detailErr == nil {
+            err.AddDetail(detail)
+        }
+    }
+    return err
+}
+
+// GinMiddlewareRecovery 恢复 panic 并以 Connect 协议写出 CodeInternal 错误, 替代 gin.Recovery;
+// 响应已经开始写出时只中断请求
+// 示例:
+//
+//	router.Use(kitctx.GinMiddlewareRecovery(kitctx.WithPanicDebug(gin.IsDebugging())))
+func GinMiddlewareRecovery(opts ...RecoveryOption) gin.HandlerFunc {
+    config := newRecoveryConfig(opts)
+    return func(c *gin.Context) {
+        defer func() {
+            recovered := recover()
+            if recovered == nil {
+                return
+            }
+            // 客户端断开时 net/http 约定的中断信号, 交还给 net/http 处理
+            if recovered == http.ErrAbortHandler {
+                panic(recovered)
+            }
+            err := config.recover(c.Request.Context(), recovered)
+            if !c.Writer.Written() {
+                _ = connect.NewErrorWriter().Write(c.Writer, c.Request, err)
+            }
+            c.Abort()
+        }()
+        c.Next()
+    }
+}
+
+// RecoveryInterceptor 恢复 Connect handler 中的 panic 并返回 CodeInternal 错误
+type RecoveryInterceptor struct {
+    config *recoveryConfig
+}
+
+var _ connect.Interceptor = (*RecoveryInterceptor)(nil)
+
+// NewRecoveryInterceptor 创建 panic 恢复拦截器, 应放在拦截器列表的最前面
+// 示例:
+//
+//	kitrouter.WithInterceptors(connect.WithInterceptors(kitctx.NewRecoveryInterceptor()))
+func NewRecoveryInterceptor(opts ...RecoveryOption) *RecoveryInterceptor {
+    return &RecoveryInterceptor{config: newRecoveryConfig(opts)}
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *RecoveryInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (res connect.AnyResponse, err error) {
+        if req.Spec().IsClient {
+            return next(ctx, req)
+        }
+        defer func() {
+            if recovered := recover(); recovered != nil {
+                if recovered == http.ErrAbortHandler {
+                    panic(recovered)
+                }
+                res, err = nil, i.config.recover(ctx, recovered)
+            }
+        }()
+        return next(ctx, req)
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *RecoveryInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *RecoveryInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return func(ctx context.Context, conn connect.StreamingHandlerConn) (err error) {
+        defer func() {
+            if recovered := recover(); recovered != nil {
+                if recovered == http.ErrAbortHandler {
+                    panic(recovered)
+                }
+                err = i.config.recover(ctx, recovered)
+            }
+        }()
+        return next(ctx, conn)
+    }
+}
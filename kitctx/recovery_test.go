@@ -0,0 +1,98 @@
+package kitctx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestGinMiddlewareRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	var reported any
+	engine := gin.New()
+	engine.Use(
+		GinMiddlewareRequestId(WithRequestIdLogger(zerolog.New(&logs))),
+		GinMiddlewareRecovery(WithPanicReporter(func(_ context.Context, recovered any, stack []byte) {
+			reported = recovered
+			if len(stack) == 0 {
+				t.Error("empty stack")
+			}
+		})),
+	)
+	engine.POST("/user.v1.UserService/GetUser", func(c *gin.Context) {
+		panic("boom")
+	})
+	req := httptest.NewRequest(http.MethodPost, "/user.v1.UserService/GetUser", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("content type = %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"internal"`) || strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+	if reported != "boom" {
+		t.Errorf("reported = %v", reported)
+	}
+	if !strings.Contains(logs.String(), `"panic":"boom"`) || !strings.Contains(logs.String(), `"request_id"`) {
+		t.Errorf("log = %s", logs.String())
+	}
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	const procedure = "/kitctx.test.Service/Panic"
+	// 未经过 GinMiddlewareRequestId 时使用全局日志, 测试中静默
+	logger := log.Logger
+	log.Logger = zerolog.Nop()
+	defer func() { log.Logger = logger }()
+	for _, debug := range []bool{false, true} {
+		mux := http.NewServeMux()
+		mux.Handle(procedure, connect.NewUnaryHandler(
+			procedure,
+			func(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+				panic("boom")
+			},
+			connect.WithInterceptors(NewRecoveryInterceptor(WithPanicDebug(debug))),
+		))
+		server := httptest.NewServer(mux)
+		client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+procedure)
+		_, err := client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+		server.Close()
+
+		var connectErr *connect.Error
+		if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeInternal {
+			t.Fatalf("debug=%v: err = %v", debug, err)
+		}
+		var info *errdetails.DebugInfo
+		for _, detail := range connectErr.Details() {
+			if value, valueErr := detail.Value(); valueErr == nil {
+				if debugInfo, ok := value.(*errdetails.DebugInfo); ok {
+					info = debugInfo
+				}
+			}
+		}
+		switch {
+		case debug && (info == nil || info.GetDetail() != "boom" || len(info.GetStackEntries()) == 0):
+			t.Errorf("debug detail = %v", info)
+		case !debug && info != nil:
+			t.Errorf("debug detail leaked: %v", info)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package kitctx
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/binary"
+    "errors"
+    "time"
+
+    "connectrpc.com/connect"
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/rs/zerolog"
+    "github.com/rs/zerolog/log"
+)
+
+// DefaultRequestIdHeader 默认读取与回写请求ID的请求头
+const DefaultRequestIdHeader = "X-Request-Id"
+
+// maxRequestIdLength 外部传入的请求ID超过该长度或包含不可见字符时重新生成
+const maxRequestIdLength = 128
+
+// requestId 记录请求ID以及是否已经由 gin 中间件写入响应头, 避免拦截器重复回写
+type requestId struct {
+    id     string
+    echoed bool
+}
+
+var requestIdKey = NewKey[requestId]("request_id")
+
+// ContextWithRequestId 将请求ID写入上下文
+func ContextWithRequestId(ctx context.Context, id string) context.Context {
+    return requestIdKey.Set(ctx, requestId{id: id})
+}
+
+// GetRequestId 从context中获取请求ID, 不存在时返回空字符串
+func GetRequestId(ctx context.Context) string {
+    value, _ := requestIdKey.Get(ctx)
+    return value.id
+}
+
+// Logger 返回上下文中带 request_id 字段的 zerolog 日志, 不存在时返回全局日志 log.Logger
+// 示例:
+//
+//	kitctx.Logger(ctx).Info().Msg("user created")
+func Logger(ctx context.Context) *zerolog.Logger {
+    logger := zerolog.Ctx(unwrapGin(ctx))
+    if logger == nil || logger.GetLevel() == zerolog.Disabled {
+        return &log.Logger
+    }
+    return logger
+}
+
+// NewUUIDv7 生成按时间排序的 UUIDv7, 默认的请求ID生成器
+func NewUUIDv7() string {
+    id, err := uuid.NewV7()
+    if err != nil {
+        return uuid.NewString()
+    }
+    return id.String()
+}
+
+// crockford ULID 使用的 Crockford Base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID 生成 26 位的 ULID: 48 位毫秒时间戳 + 80 位随机数
+func NewULID() string {
+    var data [16]byte
+    binary.BigEndian.PutUint64(data[:8], uint64(time.Now().UnixMilli())<<16)
+    _, _ = rand.Read(data[6:])
+    var out [26]byte
+    // 128 位按 5 位一组编码, 首字符只占 3 位
+    hi := binary.BigEndian.Uint64(data[:8])
+    lo := binary.BigEndian.Uint64(data[8:])
+    for i := 25; i >= 0; i-- {
+        out[i] = crockford[lo&0x1f]
+        lo = lo>>5 | hi<<59
+        hi >>= 5
+    }
+    return string(out[:])
+}
+
+// RequestIdOption 配置请求ID中间件与拦截器
+type RequestIdOption func(*requestIdConfig)
+
+type requestIdConfig struct {
+    header    string
+    generator func() string
+    logger    *zerolog.Logger
+}
+
+// WithRequestIdHeader 设置读取与回写请求ID的请求头, 默认 X-Request-Id
+func WithRequestIdHeader(header string) RequestIdOption {
+    return func(c *requestIdConfig) {
+        c.header = header
+    }
+}
+
+// WithRequestIdGenerator 设置请求ID生成器, 默认 NewUUIDv7, 可以换成 NewULID
+func WithRequestIdGenerator(generator func() string) RequestIdOption {
+    return func(c *requestIdConfig) {
+        c.generator = generator
+    }
+}
+
+// WithRequestIdLogger 设置派生请求日志的基础日志, 默认 log.Logger
+func WithRequestIdLogger(logger zerolog.Logger) RequestIdOption {
+    return func(c *requestIdConfig) {
+        c.logger = &logger
+    }
+}
+
+func newRequestIdConfig(opts []RequestIdOption) *requestIdConfig {
+    c := &requestIdConfig{
+        header:    DefaultRequestIdHeader,
+        generator: NewUUIDv7,
+    }
+    for _, opt := range opts {
+        opt(c)
+    }
+    return c
+}
+
+// resolve 使用外部传入的合法请求ID, 否则生成新的
+func (c *requestIdConfig) resolve(incoming string) string {
+    if validRequestId(incoming) {
+        return incoming
+    }
+    return c.generator()
+}
+
+// attach 将请求ID与带 request_id 字段的日志写入上下文
+func (c *requestIdConfig) attach(ctx context.Context, id string, echoed bool) context.Context {
+    ctx = requestIdKey.Set(ctx, requestId{id: id, echoed: echoed})
+    base := c.logger
+    if base == nil {
+        base = &log.Logger
+    }
+    return base.With().Str("request_id", id).Logger().WithContext(ctx)
+}
+
+func validRequestId(id string) bool {
+    if id == "" || len(id) > maxRequestIdLength {
+        return false
+    }
+    for i := 0; i < len(id); i++ {
+        if id[i] < 0x21 || id[i] > 0x7e {
+            return false
+        }
+    }
+    return true
+}
+
+// GinMiddlewareRequestId 读取或生成请求ID, 写入请求上下文与日志, 并在响应头中回写
+// 示例:
+//
+//	router.Use(kitctx.GinMiddlewareRequestId(kitctx.WithRequestIdGenerator(kitctx.NewULID)))
+func GinMiddlewareRequestId(opts ...RequestIdOption) gin.HandlerFunc {
+    config := newRequestIdConfig(opts)
+    return func(c *gin.Context) {
+        id := config.resolve(c.GetHeader(config.header))
+        c.Header(config.header, id)
+        c.Request = c.Request.WithContext(config.attach(c.Request.Context(), id, true))
+        c.Next()
+    }
+}
+
+// RequestIdInterceptor 服务端读取或生成请求ID, 写入上下文与日志, 并回写到响应头与 connect.Error 的 metadata;
+// 请求已经过 GinMiddlewareRequestId 时沿用其请求ID且不重复回写.
+// 客户端把上下文中的请求ID放入请求头, 用于跨服务传递.
+// 普通错误不会被包装, 需要在错误中携带请求ID时把 ErrorMapInterceptor 放在它之后(内层),
+// 先由 ErrorMapInterceptor 转换为 connect.Error
+type RequestIdInterceptor struct {
+    config *requestIdConfig
+}
+
+var _ connect.Interceptor = (*RequestIdInterceptor)(nil)
+
+// NewRequestIdInterceptor 创建请求ID拦截器
+// 示例:
+//
+//	kitrouter.WithInterceptors(connect.WithInterceptors(
+//	    kitctx.NewRequestIdInterceptor(),
+//	    kitctx.NewErrorMapInterceptor(),
+//	))
+func NewRequestIdInterceptor(opts ...RequestIdOption) *RequestIdInterceptor {
+    return &RequestIdInterceptor{config: newRequestIdConfig(opts)}
+}
+
+// handlerContext 服务端确定请求ID, 返回需要回写的请求ID, 已回写时为空
+func (i *RequestIdInterceptor) handlerContext(ctx context.Context, incoming string) (context.Context, string) {
+    if current, ok := requestIdKey.Get(ctx); ok && current.id != "" {
+        if current.echoed {
+            return ctx, ""
+        }
+        return ctx, current.id
+    }
+    id := i.config.resolve(incoming)
+    return i.config.attach(ctx, id, false), id
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *RequestIdInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+    return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+        if req.Spec().IsClient {
+            if id := GetRequestId(ctx); id != "" && req.Header().Get(i.config.header) == "" {
+                req.Header().Set(i.config.header, id)
+            }
+            return next(ctx, req)
+        }
+        ctx, echo := i.handlerContext(ctx, req.Header().Get(i.config.header))
+        res, err := next(ctx, req)
+        if echo == "" {
+            return res, err
+        }
+        if err != nil {
+            return nil, i.withErrorMeta(err, echo)
+        }
+        if res != nil {
+            res.Header().Set(i.config.header, echo)
+        }
+        return res, nil
+    }
+}
+
+// WrapStreamingClient implements connect.Interceptor.
+func (i *RequestIdInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+    return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+        conn := next(ctx, spec)
+        if id := GetRequestId(ctx); id != "" && conn.RequestHeader().Get(i.config.header) == "" {
+            conn.RequestHeader().Set(i.config.header, id)
+        }
+        return conn
+    }
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *RequestIdInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+    return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+        ctx, echo := i.handlerContext(ctx, conn.RequestHeader().Get(i.config.header))
+        if echo == "" {
+            return next(ctx, conn)
+        }
+        conn.ResponseHeader().Set(i.config.header, echo)
+        if err := next(ctx, conn); err != nil {
+            return i.withErrorMeta(err, echo)
+        }
+        return nil
+    }
+}
+
+// withErrorMeta 把请求ID写入 connect.Error 的 metadata; 其他错误原样返回, 留给 ErrorMapInterceptor 等外层拦截器处理
+func (i *RequestIdInterceptor) withErrorMeta(err error, id string) error {
+    var connectErr *connect.Error
+    if errors.As(err, &connectErr) {
+        connectErr.Meta().Set(i.config.header, id)
+    }
+    return err
+}
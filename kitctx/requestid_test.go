@@ -0,0 +1,165 @@
+package kitctx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestNewULID(t *testing.T) {
+	a, b := NewULID(), NewULID()
+	if len(a) != 26 || a == b {
+		t.Fatalf("NewULID() = %q, %q", a, b)
+	}
+	for _, r := range a {
+		if !strings.ContainsRune(crockford, r) {
+			t.Fatalf("NewULID() = %q contains %q", a, r)
+		}
+	}
+}
+
+func TestGinMiddlewareRequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	engine := gin.New()
+	engine.Use(GinMiddlewareRequestId(
+		WithRequestIdGenerator(func() string { return "generated" }),
+		WithRequestIdLogger(zerolog.New(&logs)),
+	))
+	engine.GET("/", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		Logger(ctx).Info().Msg("hello")
+		c.String(http.StatusOK, GetRequestId(ctx))
+	})
+	tests := []struct {
+		name     string
+		incoming string
+		want     string
+	}{
+		{"generate", "", "generated"},
+		{"accept incoming", "abc-123", "abc-123"},
+		{"reject invalid incoming", "has space", "generated"},
+		{"reject oversized incoming", strings.Repeat("x", maxRequestIdLength+1), "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(DefaultRequestIdHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if got := rec.Header().Get(DefaultRequestIdHeader); got != tt.want {
+				t.Errorf("response header = %q, want %q", got, tt.want)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("context request id = %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(logs.String(), `"request_id":"`+tt.want+`"`) {
+				t.Errorf("log = %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestLogger_Fallback(t *testing.T) {
+	if Logger(context.Background()) == nil {
+		t.Fatal("Logger() returned nil")
+	}
+}
+
+func TestRequestIdInterceptor(t *testing.T) {
+	const procedure = "/kitctx.test.Service/Call"
+	var seen string
+	fail := false
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(
+		procedure,
+		func(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+			seen = GetRequestId(ctx)
+			if fail {
+				return nil, NewInternal("boom")
+			}
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		},
+		connect.WithInterceptors(NewRequestIdInterceptor(WithRequestIdGenerator(func() string { return "server-id" }))),
+	))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](
+		server.Client(),
+		server.URL+procedure,
+		connect.WithInterceptors(NewRequestIdInterceptor()),
+	)
+
+	// 客户端把上下文中的请求ID传给服务端, 服务端回写到响应头
+	res, err := client.CallUnary(ContextWithRequestId(context.Background(), "client-id"), connect.NewRequest(&emptypb.Empty{}))
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if seen != "client-id" {
+		t.Errorf("server saw %q", seen)
+	}
+	if got := res.Header().Get(DefaultRequestIdHeader); got != "client-id" {
+		t.Errorf("response header = %q", got)
+	}
+
+	// 没有请求ID时服务端生成, 并写入错误的 metadata
+	fail = true
+	_, err = client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	if got := connectErr.Meta().Get(DefaultRequestIdHeader); got != "server-id" {
+		t.Errorf("error meta = %q", got)
+	}
+	if values := connectErr.Meta().Values(DefaultRequestIdHeader); len(values) != 1 {
+		t.Errorf("error meta values = %v", values)
+	}
+}
+
+// TestRequestIdInterceptor_PlainError 普通错误原样交给内层的 ErrorMapInterceptor, 映射后带有请求ID
+func TestRequestIdInterceptor_PlainError(t *testing.T) {
+	interceptor := NewRequestIdInterceptor(WithRequestIdGenerator(func() string { return "server-id" }))
+	handler := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, fmt.Errorf("load user: %w", sql.ErrNoRows)
+	}
+	_, err := interceptor.WrapUnary(handler)(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	var connectErr *connect.Error
+	if !errors.Is(err, sql.ErrNoRows) || errors.As(err, &connectErr) {
+		t.Errorf("plain error wrapped: %v", err)
+	}
+
+	const procedure = "/kitctx.test.Service/Call"
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(
+		procedure,
+		func(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+			return nil, fmt.Errorf("load user: %w", sql.ErrNoRows)
+		},
+		connect.WithInterceptors(interceptor, NewErrorMapInterceptor()),
+	))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](server.Client(), server.URL+procedure)
+	_, err = client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeNotFound {
+		t.Fatalf("err = %v, want CodeNotFound", err)
+	}
+	if got := connectErr.Meta().Get(DefaultRequestIdHeader); got != "server-id" {
+		t.Errorf("error meta = %q", got)
+	}
+}
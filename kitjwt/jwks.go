@@ -0,0 +1,186 @@
+package kitjwt
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// errUnsupportedKey 表示 JWK 的密钥类型或算法不受支持
+var errUnsupportedKey = errors.New("unsupported jwk")
+
+// JWK RFC 7517 JSON Web Key，包含公钥参数与对称密钥，不包含私钥参数
+type JWK struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid,omitempty"`
+    Use string `json:"use,omitempty"`
+    Alg string `json:"alg,omitempty"`
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+    Y   string `json:"y,omitempty"`
+    N   string `json:"n,omitempty"`
+    E   string `json:"e,omitempty"`
+    // K 对称密钥(kty 为 oct)，只用于加载密钥，不会发布
+    K string `json:"k,omitempty"`
+}
+
+// JWKSet RFC 7517 JWK Set
+type JWKSet struct {
+    Keys []JWK `json:"keys"`
+}
+
+// NewJWK 将公钥转换为 JWK，支持 ed25519(OKP)、RSA 与 ECDSA(EC) 公钥
+func NewJWK(kid string, method jwt.SigningMethod, publicKey crypto.PublicKey) (JWK, error) {
+    jwk := JWK{Kid: kid, Use: "sig", Alg: method.Alg()}
+    switch key := publicKey.(type) {
+    case ed25519.PublicKey:
+        jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(key)
+    case *rsa.PublicKey:
+        jwk.Kty = "RSA"
+        jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+        jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+    case *ecdsa.PublicKey:
+        size := (key.Curve.Params().BitSize + 7) / 8
+        jwk.Kty, jwk.Crv = "EC", key.Curve.Params().Name
+        jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+        jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+    default:
+        return JWK{}, fmt.Errorf("%w: public key type %T", errUnsupportedKey, publicKey)
+    }
+    return jwk, nil
+}
+
+// Thumbprint 计算 RFC 7638 JWK 指纹，可用作 kid
+func (j JWK) Thumbprint() string {
+    // 按 RFC 7638 要求，只包含必需成员，按字典序排列且没有空白
+    var members []string
+    switch j.Kty {
+    case "OKP":
+        members = []string{"crv", j.Crv, "kty", j.Kty, "x", j.X}
+    case "RSA":
+        members = []string{"e", j.E, "kty", j.Kty, "n", j.N}
+    case "EC":
+        members = []string{"crv", j.Crv, "kty", j.Kty, "x", j.X, "y", j.Y}
+    default:
+        members = []string{"k", j.K, "kty", j.Kty}
+    }
+    var canonical strings.Builder
+    canonical.WriteByte('{')
+    for i := 0; i < len(members); i += 2 {
+        if i > 0 {
+            canonical.WriteByte(',')
+        }
+        canonical.WriteString(strconv.Quote(members[i]) + ":" + strconv.Quote(members[i+1]))
+    }
+    canonical.WriteByte('}')
+    sum := sha256.Sum256([]byte(canonical.String()))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicKey 解析 JWK 中的公钥与签名算法，alg 为空时按密钥类型推断(OKP 为 EdDSA，RSA 为 RS256，EC 按曲线)
+func (j JWK) PublicKey() (crypto.PublicKey, jwt.SigningMethod, error) {
+    decode := base64.RawURLEncoding.DecodeString
+    var publicKey crypto.PublicKey
+    alg := j.Alg
+    switch j.Kty {
+    case "OKP":
+        x, err := decode(j.X)
+        if err != nil || j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+            return nil, nil, fmt.Errorf("%w: invalid OKP key %q", errUnsupportedKey, j.Kid)
+        }
+        publicKey = ed25519.PublicKey(x)
+        if alg == "" {
+            alg = jwt.SigningMethodEdDSA.Alg()
+        }
+    case "RSA":
+        n, errN := decode(j.N)
+        e, errE := decode(j.E)
+        if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+            return nil, nil, fmt.Errorf("%w: invalid RSA key %q", errUnsupportedKey, j.Kid)
+        }
+        publicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+        if alg == "" {
+            alg = jwt.SigningMethodRS256.Alg()
+        }
+    case "EC":
+        var curve elliptic.Curve
+        var defaultAlg string
+        switch j.Crv {
+        case "P-256":
+            curve, defaultAlg = elliptic.P256(), jwt.SigningMethodES256.Alg()
+        case "P-384":
+            curve, defaultAlg = elliptic.P384(), jwt.SigningMethodES384.Alg()
+        case "P-521":
+            curve, defaultAlg = elliptic.P521(), jwt.SigningMethodES512.Alg()
+        default:
+            return nil, nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, j.Crv)
+        }
+        x, errX := decode(j.X)
+        y, errY := decode(j.Y)
+        if errX != nil || errY != nil {
+            return nil, nil, fmt.Errorf("%w: invalid EC key %q", errUnsupportedKey, j.Kid)
+        }
+        key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+        if !curve.IsOnCurve(key.X, key.Y) {
+            return nil, nil, fmt.Errorf("%w: EC key %q not on curve", errUnsupportedKey, j.Kid)
+        }
+        publicKey = key
+        if alg == "" {
+            alg = defaultAlg
+        }
+    default:
+        return nil, nil, fmt.Errorf("%w: kty %q", errUnsupportedKey, j.Kty)
+    }
+    method := jwt.GetSigningMethod(alg)
+    if method == nil {
+        return nil, nil, fmt.Errorf("%w: alg %q", errUnsupportedKey, alg)
+    }
+    return publicKey, method, nil
+}
+
+// JWKS 返回所有可公开的验证密钥(包括已退役的密钥)，按 kid 排序; 对称密钥不会公开
+func (k *Keyring) JWKS() JWKSet {
+    set := JWKSet{Keys: []JWK{}}
+    for _, kid := range k.KeyIDs() {
+        k.mu.RLock()
+        key, ok := k.keys[kid]
+        k.mu.RUnlock()
+        if !ok {
+            continue
+        }
+        if jwk, err := NewJWK(kid, key.method, key.public); err == nil {
+            set.Keys = append(set.Keys, jwk)
+        }
+    }
+    return set
+}
+
+// JWKSHandler 以 JWK Set 发布 keyring 中的公钥，供其他服务通过 RemoteJWKS 验证 token
+// 示例:
+//
+//	router.GET("/.well-known/jwks.json", gin.WrapH(kitjwt.JWKSHandler(keyring)))
+func JWKSHandler(keyring *Keyring) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, err := json.Marshal(keyring.JWKS())
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/jwk-set+json")
+        w.Header().Set("Cache-Control", "public, max-age=300")
+        _, _ = w.Write(body)
+    })
+}
@@ -0,0 +1,243 @@
+package kitjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWK_RoundTrip(t *testing.T) {
+	edPublic, _, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method    jwt.SigningMethod
+		publicKey any
+		kty       string
+	}{
+		{jwt.SigningMethodEdDSA, edPublic, "OKP"},
+		{jwt.SigningMethodRS256, &rsaKey.PublicKey, "RSA"},
+		{jwt.SigningMethodES384, &ecKey.PublicKey, "EC"},
+	}
+	for _, tt := range tests {
+		jwk, err := NewJWK("kid-"+tt.kty, tt.method, tt.publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jwk.Kty != tt.kty || jwk.Alg != tt.method.Alg() || jwk.Use != "sig" {
+			t.Errorf("jwk = %+v", jwk)
+		}
+		publicKey, method, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.kty, err)
+		}
+		if method != tt.method || !reflect.DeepEqual(publicKey, tt.publicKey) {
+			t.Errorf("%s: round trip mismatch", tt.kty)
+		}
+	}
+	if _, _, err = (JWK{Kty: "oct"}).PublicKey(); err == nil {
+		t.Error("parsed symmetric jwk")
+	}
+	if _, _, err = (JWK{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"}).PublicKey(); err == nil {
+		t.Error("parsed point not on curve")
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	keyring := NewKeyring()
+	if err := keyring.Add("v1", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Rotate("v2", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Retire("v1"); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	JWKSHandler(keyring).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/jwk-set+json" {
+		t.Errorf("content type = %q", got)
+	}
+	var set JWKSet
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+	// 已退役的密钥仍然发布, 以便验证之前签发的 token
+	if len(set.Keys) != 2 || set.Keys[0].Kid != "v1" || set.Keys[1].Kid != "v2" {
+		t.Fatalf("keys = %+v", set.Keys)
+	}
+	if key := set.Keys[1]; key.Kty != "OKP" || key.Crv != "Ed25519" || key.Alg != "EdDSA" || key.X == "" {
+		t.Errorf("jwk = %+v", key)
+	}
+}
+
+// newRemoteTestServer 以 keyring 为远程 JWKS, 返回拉取次数
+func newRemoteTestServer(t *testing.T, keyring *Keyring) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var fetches atomic.Int32
+	handler := JWKSHandler(keyring)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestRemoteJWKS(t *testing.T) {
+	issuerKeyring := NewKeyring()
+	if err := issuerKeyring.Add("v1", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	issuer := New(issuerKeyring, newTestClaims)
+	server, fetches := newRemoteTestServer(t, issuerKeyring)
+
+	now := time.Now()
+	remote := NewRemoteJWKS(server.URL, WithJWKSMinRefreshInterval(time.Minute), WithJWKSRefreshInterval(time.Hour))
+	remote.now = func() time.Time { return now }
+	verifier := NewVerifier(remote, newTestClaims)
+
+	if claims, err := verifier.Parse(signTest(t, issuer, "v1 user")); err != nil || claims.Subject != "v1 user" {
+		t.Fatalf("claims = %v, err = %v", claims, err)
+	}
+	if _, err := verifier.Parse(signTest(t, issuer, "cached")); err != nil || fetches.Load() != 1 {
+		t.Errorf("cached parse: fetches = %d, err = %v", fetches.Load(), err)
+	}
+	if _, err := verifier.Sign(newTestClaims()); err == nil {
+		t.Error("verifier signed a token")
+	}
+
+	// 轮换后未知 kid 受最小间隔限制, 间隔之后重新拉取
+	if err := issuerKeyring.Rotate("v2", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	rotated := signTest(t, issuer, "v2 user")
+	if _, err := verifier.Parse(rotated); !IsInvalidToken(err) || fetches.Load() != 1 {
+		t.Errorf("within min interval: fetches = %d, err = %v", fetches.Load(), err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := verifier.Parse(rotated); err != nil || fetches.Load() != 2 {
+		t.Errorf("after min interval: fetches = %d, err = %v", fetches.Load(), err)
+	}
+	// 未知 kid 反复出现时不会频繁拉取
+	stranger := NewKeyring()
+	if err := stranger.Add("v3", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	forged := signTest(t, New(stranger, newTestClaims), "forged")
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Parse(forged); !IsInvalidToken(err) {
+			t.Errorf("forged token accepted: %v", err)
+		}
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("fetches after unknown kids = %d", fetches.Load())
+	}
+
+	// 缓存过期后重新拉取
+	now = now.Add(time.Hour)
+	if _, err := verifier.Parse(rotated); err != nil || fetches.Load() != 3 {
+		t.Errorf("after expiry: fetches = %d, err = %v", fetches.Load(), err)
+	}
+}
+
+func TestRemoteJWKS_FetchFailureKeepsCache(t *testing.T) {
+	keyring := NewKeyring()
+	if err := keyring.Add("v1", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	healthy := JWKSHandler(keyring)
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		healthy.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	remote := NewRemoteJWKS(server.URL, WithJWKSHTTPClient(server.Client()))
+	remote.now = func() time.Time { return now }
+	if err := remote.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	down.Store(true)
+	if err := remote.Refresh(t.Context()); err == nil {
+		t.Error("refresh of failing endpoint succeeded")
+	}
+	now = now.Add(2 * DefaultJWKSRefreshInterval)
+	if _, err := NewVerifier(remote, newTestClaims).Parse(signTest(t, New(keyring, newTestClaims), "x")); err != nil {
+		t.Errorf("parse with cached keys: %v", err)
+	}
+}
+
+func TestRemoteJWKS_RefreshTimeout(t *testing.T) {
+	keyring := NewKeyring()
+	if err := keyring.Add("v1", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	healthy := JWKSHandler(keyring)
+	var slow atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			// 直到客户端放弃请求才返回
+			<-r.Context().Done()
+			return
+		}
+		healthy.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	token := signTest(t, New(keyring, newTestClaims), "x")
+	const timeout = 50 * time.Millisecond
+	const bound = time.Second
+
+	// 没有缓存时在超时后返回错误
+	slow.Store(true)
+	cold := NewVerifier(NewRemoteJWKS(server.URL, WithJWKSRefreshTimeout(timeout)), newTestClaims)
+	start := time.Now()
+	if _, err := cold.Parse(token); !IsInvalidToken(err) {
+		t.Errorf("parse without keys: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > bound {
+		t.Errorf("parse without keys took %s", elapsed)
+	}
+
+	// 缓存过期后远程缓慢, 在超时后继续使用已缓存的密钥
+	slow.Store(false)
+	now := time.Now()
+	remote := NewRemoteJWKS(server.URL, WithJWKSRefreshTimeout(timeout))
+	remote.now = func() time.Time { return now }
+	if err := remote.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	slow.Store(true)
+	now = now.Add(2 * DefaultJWKSRefreshInterval)
+	start = time.Now()
+	if _, err := NewVerifier(remote, newTestClaims).Parse(token); err != nil {
+		t.Errorf("parse with cached keys: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < timeout || elapsed > bound {
+		t.Errorf("parse with cached keys took %s, want about %s", elapsed, timeout)
+	}
+}
@@ -0,0 +1,259 @@
+package kitjwt
+
+import (
+    "crypto"
+    "crypto/ed25519"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+    // errKeyNotFound 表示 keyring 中没有对应 kid 的密钥
+    errKeyNotFound = errors.New("key not found")
+    // errNoActiveKey 表示 keyring 中没有可用于签名的密钥
+    errNoActiveKey = errors.New("no active signing key")
+)
+
+// IsKeyNotFound 判断错误是否为密钥不存在错误
+func IsKeyNotFound(err error) bool {
+    return errors.Is(err, errKeyNotFound)
+}
+
+// keyringKey keyring 中的一个密钥，private 为 nil 时只能用于验证
+type keyringKey struct {
+    method  jwt.SigningMethod
+    private crypto.PrivateKey
+    public  crypto.PublicKey
+}
+
+// Keyring 保存签名密钥与验证密钥，支持运行时轮换
+//   - 使用 active 密钥签名，token 头部带有 kid
+//   - 验证时按 kid 从当前与已退役的密钥中选择，没有 kid 的 token(轮换前签发)依次尝试所有密钥
+//
+// 示例:
+//
+//	keyring := kitjwt.NewKeyring()
+//	_ = keyring.Add("2024-01", keyHex)
+//	// 轮换: 新 token 使用新密钥签名，旧 token 仍然可以验证
+//	_ = keyring.Rotate("2024-06", newKeyHex)
+//	// 旧 token 全部过期后移除
+//	keyring.Remove("2024-01")
+type Keyring struct {
+    mu     sync.RWMutex
+    active string
+    keys   map[string]*keyringKey
+}
+
+// NewKeyring 创建空的 keyring
+func NewKeyring() *Keyring {
+    return &Keyring{keys: make(map[string]*keyringKey)}
+}
+
+// Thumbprint 计算 ed25519 公钥的 RFC 7638 JWK 指纹，可用作 kid; 其他类型的公钥见 JWK.Thumbprint
+func Thumbprint(publicKey ed25519.PublicKey) string {
+    return JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(publicKey)}.Thumbprint()
+}
+
+// parseEd25519Hex 解析十六进制编码的 ed25519 私钥
+func parseEd25519Hex(keyHex string) (ed25519.PrivateKey, error) {
+    bytes, err := hex.DecodeString(keyHex)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode jwt key: %w", err)
+    }
+    if len(bytes) != ed25519.PrivateKeySize {
+        return nil, fmt.Errorf("jwt key length incorrect: expected %d bytes, got %d", ed25519.PrivateKeySize, len(bytes))
+    }
+    return ed25519.PrivateKey(bytes), nil
+}
+
+// Add 添加十六进制编码的 ed25519 私钥(见 GenerateKey)，kid 为空时使用公钥指纹(见 Thumbprint)
+// keyring 中还没有 active 密钥时，该密钥成为 active 密钥
+func (k *Keyring) Add(kid, keyHex string) error {
+    privateKey, err := parseEd25519Hex(keyHex)
+    if err != nil {
+        return err
+    }
+    if kid == "" {
+        kid = Thumbprint(privateKey.Public().(ed25519.PublicKey))
+    }
+    return k.add(kid, &keyringKey{method: jwt.SigningMethodEdDSA, private: privateKey, public: privateKey.Public()})
+}
+
+// AddPublicKey 添加只用于验证的 ed25519 公钥，如其他服务签发 token 使用的密钥
+func (k *Keyring) AddPublicKey(kid string, publicKey ed25519.PublicKey) error {
+    if len(publicKey) != ed25519.PublicKeySize {
+        return fmt.Errorf("jwt public key length incorrect: expected %d bytes, got %d", ed25519.PublicKeySize, len(publicKey))
+    }
+    if kid == "" {
+        kid = Thumbprint(publicKey)
+    }
+    return k.add(kid, &keyringKey{method: jwt.SigningMethodEdDSA, public: publicKey})
+}
+
+// AddKey 添加任意算法的密钥，key 可以是私钥(签名与验证)、公钥(只用于验证)或 HMAC 密钥([]byte):
+//   - EdDSA: ed25519.PrivateKey / ed25519.PublicKey
+//   - RS256/RS384/RS512/PS256/PS384/PS512: *rsa.PrivateKey / *rsa.PublicKey
+//   - ES256/ES384/ES512: *ecdsa.PrivateKey / *ecdsa.PublicKey，曲线需与算法一致
+//   - HS256/HS384/HS512: []byte，必须指定 kid
+//
+// kid 为空时使用公钥的 JWK 指纹; 密钥材料可以用 ParseKey 从 PEM、DER、hex 或 JWK 加载
+// 示例:
+//
+//	key, err := kitjwt.ParseKey(pemBytes)
+//	err = keyring.AddKey("idp-2024", jwt.SigningMethodRS256, key)
+func (k *Keyring) AddKey(kid string, method jwt.SigningMethod, key interface{}) error {
+    private, public, err := checkKey(method, key)
+    if err != nil {
+        return err
+    }
+    if kid == "" {
+        jwk, jwkErr := NewJWK("", method, public)
+        if jwkErr != nil {
+            return fmt.Errorf("jwt key id required: %w", jwkErr)
+        }
+        kid = jwk.Thumbprint()
+    }
+    return k.add(kid, &keyringKey{method: method, private: private, public: public})
+}
+
+func (k *Keyring) add(kid string, key *keyringKey) error {
+    k.mu.Lock()
+    defer k.mu.Unlock()
+    if _, ok := k.keys[kid]; ok {
+        return fmt.Errorf("jwt key %q already exists", kid)
+    }
+    k.keys[kid] = key
+    if k.active == "" && key.private != nil {
+        k.active = kid
+    }
+    return nil
+}
+
+// Activate 使用 kid 对应的密钥签名新 token，之前的 active 密钥只用于验证
+func (k *Keyring) Activate(kid string) error {
+    k.mu.Lock()
+    defer k.mu.Unlock()
+    key, ok := k.keys[kid]
+    if !ok {
+        return fmt.Errorf("jwt key %q: %w", kid, errKeyNotFound)
+    }
+    if key.private == nil {
+        return fmt.Errorf("jwt key %q has no private key", kid)
+    }
+    k.active = kid
+    return nil
+}
+
+// Rotate 添加新的私钥并立即用于签名，之前的 active 密钥只用于验证，等同于 Add 后 Activate
+func (k *Keyring) Rotate(kid, keyHex string) error {
+    privateKey, err := parseEd25519Hex(keyHex)
+    if err != nil {
+        return err
+    }
+    if kid == "" {
+        kid = Thumbprint(privateKey.Public().(ed25519.PublicKey))
+    }
+    if err = k.add(kid, &keyringKey{method: jwt.SigningMethodEdDSA, private: privateKey, public: privateKey.Public()}); err != nil {
+        return err
+    }
+    return k.Activate(kid)
+}
+
+// Retire 退役密钥: 丢弃私钥，只保留公钥用于验证之前签发的 token; 不能退役 active 密钥
+func (k *Keyring) Retire(kid string) error {
+    k.mu.Lock()
+    defer k.mu.Unlock()
+    key, ok := k.keys[kid]
+    if !ok {
+        return fmt.Errorf("jwt key %q: %w", kid, errKeyNotFound)
+    }
+    if kid == k.active {
+        return fmt.Errorf("jwt key %q is active, activate another key first", kid)
+    }
+    k.keys[kid] = &keyringKey{method: key.method, public: key.public}
+    return nil
+}
+
+// Remove 移除密钥，之后由它签发的 token 无法通过验证; 移除 active 密钥后无法签名，直到 Activate 其他密钥
+func (k *Keyring) Remove(kid string) {
+    k.mu.Lock()
+    defer k.mu.Unlock()
+    delete(k.keys, kid)
+    if kid == k.active {
+        k.active = ""
+    }
+}
+
+// ActiveKeyID 返回当前用于签名的 kid，没有时返回空字符串
+func (k *Keyring) ActiveKeyID() string {
+    k.mu.RLock()
+    defer k.mu.RUnlock()
+    return k.active
+}
+
+// KeyIDs 返回所有密钥的 kid，按字典序排列
+func (k *Keyring) KeyIDs() []string {
+    k.mu.RLock()
+    defer k.mu.RUnlock()
+    kids := make([]string, 0, len(k.keys))
+    for kid := range k.keys {
+        kids = append(kids, kid)
+    }
+    sort.Strings(kids)
+    return kids
+}
+
+// PublicKey 返回 kid 对应的公钥
+func (k *Keyring) PublicKey(kid string) (crypto.PublicKey, bool) {
+    k.mu.RLock()
+    defer k.mu.RUnlock()
+    key, ok := k.keys[kid]
+    if !ok {
+        return nil, false
+    }
+    return key.public, true
+}
+
+// signingKey 返回 active 密钥
+func (k *Keyring) signingKey() (string, *keyringKey, error) {
+    k.mu.RLock()
+    defer k.mu.RUnlock()
+    key, ok := k.keys[k.active]
+    if !ok || key.private == nil {
+        return "", nil, errNoActiveKey
+    }
+    return k.active, key, nil
+}
+
+// VerificationKey 按 token 头部的 kid 选择验证密钥，并检查签名算法，实现 KeySet
+func (k *Keyring) VerificationKey(token *jwt.Token) (interface{}, error) {
+    k.mu.RLock()
+    defer k.mu.RUnlock()
+    if kid, ok := token.Header["kid"].(string); ok {
+        key, ok := k.keys[kid]
+        if !ok {
+            return nil, errKeyNotFound
+        }
+        if key.method.Alg() != token.Method.Alg() {
+            return nil, errUnexpectedSigningMethod
+        }
+        return key.public, nil
+    }
+    // 没有 kid 时尝试所有算法匹配的密钥
+    keySet := jwt.VerificationKeySet{}
+    for _, key := range k.keys {
+        if key.method.Alg() == token.Method.Alg() {
+            keySet.Keys = append(keySet.Keys, key.public)
+        }
+    }
+    if len(keySet.Keys) == 0 {
+        return nil, errUnexpectedSigningMethod
+    }
+    return keySet, nil
+}
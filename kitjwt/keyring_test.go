@@ -0,0 +1,166 @@
+package kitjwt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKey(t *testing.T) string {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{}
+}
+
+func signTest(t *testing.T, j JWT[*jwt.RegisteredClaims], subject string) string {
+	t.Helper()
+	token, err := j.Sign(&jwt.RegisteredClaims{Subject: subject, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func tokenKid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token[len("Bearer "):], &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	keyring := NewKeyring()
+	if err := keyring.Add("v1", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	j := New(keyring, newTestClaims)
+
+	before := signTest(t, j, "before")
+	if kid := tokenKid(t, before); kid != "v1" {
+		t.Errorf("kid before rotation = %q", kid)
+	}
+	if err := keyring.Rotate("v2", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	after := signTest(t, j, "after")
+	if kid := tokenKid(t, after); kid != "v2" || keyring.ActiveKeyID() != "v2" {
+		t.Errorf("kid after rotation = %q, active = %q", kid, keyring.ActiveKeyID())
+	}
+	for _, token := range []string{before, after} {
+		if _, err := j.Parse(token, jwt.WithExpirationRequired()); err != nil {
+			t.Errorf("parse %s: %v", tokenKid(t, token), err)
+		}
+	}
+
+	// 退役后仍可验证, 但不能重新用于签名
+	if err := keyring.Retire("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Parse(before); err != nil {
+		t.Errorf("parse token of retired key: %v", err)
+	}
+	if err := keyring.Activate("v1"); err == nil {
+		t.Error("activated retired key")
+	}
+	if err := keyring.Retire("v2"); err == nil {
+		t.Error("retired active key")
+	}
+
+	// 移除后由它签发的 token 失效
+	keyring.Remove("v1")
+	if _, err := j.Parse(before); !IsInvalidToken(err) {
+		t.Errorf("parse token of removed key: err = %v", err)
+	}
+	if _, err := j.Parse(after); err != nil {
+		t.Errorf("parse current token: %v", err)
+	}
+	if got := keyring.KeyIDs(); len(got) != 1 || got[0] != "v2" {
+		t.Errorf("key ids = %v", got)
+	}
+
+	keyring.Remove("v2")
+	if _, err := j.Sign(newTestClaims()); err == nil {
+		t.Error("signed without active key")
+	}
+}
+
+func TestKeyring_LegacyTokenWithoutKid(t *testing.T) {
+	keyHex := newTestKey(t)
+	privateKey, err := parseEd25519Hex(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 引入 keyring 之前签发的 token 没有 kid
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &jwt.RegisteredClaims{Subject: "legacy"}).SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := NewKeyring()
+	if err = keyring.Add("", keyHex); err != nil {
+		t.Fatal(err)
+	}
+	if err = keyring.Rotate("v2", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	j := New(keyring, newTestClaims)
+	claims, err := j.Parse("Bearer " + legacy)
+	if err != nil || claims.Subject != "legacy" {
+		t.Errorf("claims = %v, err = %v", claims, err)
+	}
+}
+
+func TestKeyring_UnknownKid(t *testing.T) {
+	issuer := NewKeyring()
+	if err := issuer.Add("other", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	token := signTest(t, New(issuer, newTestClaims), "x")
+
+	verifier := NewKeyring()
+	if err := verifier.Add("v1", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(verifier, newTestClaims).Parse(token); !IsInvalidToken(err) {
+		t.Errorf("err = %v", err)
+	}
+
+	// 只有公钥的 keyring 可以验证但不能签名
+	publicKey, _ := issuer.PublicKey("other")
+	if err := verifier.AddPublicKey("other", publicKey.(ed25519.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(verifier, newTestClaims).Parse(token); err != nil {
+		t.Errorf("parse with public key: %v", err)
+	}
+	if err := verifier.Activate("other"); err == nil {
+		t.Error("activated public key")
+	}
+	if err := verifier.Add("v1", newTestKey(t)); err == nil {
+		t.Error("added duplicate kid")
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 8037 Appendix A.3
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Thumbprint(x); got != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("thumbprint = %q", got)
+	}
+}
@@ -0,0 +1,244 @@
+package kitjwt
+
+import (
+    "bytes"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "math/big"
+    "strings"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// errInvalidKey 表示密钥与签名算法不匹配或无法解析
+var errInvalidKey = errors.New("invalid jwt key")
+
+// minRSAKeyBits RSA 密钥的最小长度，短于此长度的密钥可被分解，签名可被伪造
+const minRSAKeyBits = 2048
+
+// checkKey 检查密钥是否适用于签名算法，返回私钥(只能验证时为 nil)与验证用的公钥
+func checkKey(method jwt.SigningMethod, key interface{}) (private, public interface{}, err error) {
+    switch m := method.(type) {
+    case *jwt.SigningMethodEd25519:
+        switch k := key.(type) {
+        case ed25519.PrivateKey:
+            if len(k) == ed25519.PrivateKeySize {
+                return k, k.Public(), nil
+            }
+        case ed25519.PublicKey:
+            if len(k) == ed25519.PublicKeySize {
+                return nil, k, nil
+            }
+        }
+    case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+        var publicKey *rsa.PublicKey
+        switch k := key.(type) {
+        case *rsa.PrivateKey:
+            private, publicKey = k, &k.PublicKey
+        case *rsa.PublicKey:
+            publicKey = k
+        }
+        if publicKey != nil {
+            if bits := publicKey.N.BitLen(); bits < minRSAKeyBits {
+                return nil, nil, fmt.Errorf("%w: RSA key is %d bits, at least %d required", errInvalidKey, bits, minRSAKeyBits)
+            }
+            return private, publicKey, nil
+        }
+    case *jwt.SigningMethodECDSA:
+        var publicKey *ecdsa.PublicKey
+        switch k := key.(type) {
+        case *ecdsa.PrivateKey:
+            private, publicKey = k, &k.PublicKey
+        case *ecdsa.PublicKey:
+            publicKey = k
+        }
+        // ES256 只能使用 P-256，其余同理
+        if publicKey != nil && publicKey.Curve.Params().BitSize == m.CurveBits {
+            return private, publicKey, nil
+        }
+    case *jwt.SigningMethodHMAC:
+        if k, ok := key.([]byte); ok && len(k) > 0 {
+            return k, k, nil
+        }
+    }
+    return nil, nil, fmt.Errorf("%w: %T cannot be used with %s", errInvalidKey, key, method.Alg())
+}
+
+// ParseKey 解析密钥材料，依次识别 PEM、JWK(JSON)、hex 与 DER，返回值可直接传给 Keyring.AddKey
+func ParseKey(data []byte) (interface{}, error) {
+    trimmed := bytes.TrimSpace(data)
+    switch {
+    case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+        return ParseKeyPEM(trimmed)
+    case bytes.HasPrefix(trimmed, []byte("{")):
+        return ParseKeyJWK(trimmed)
+    }
+    if key, err := ParseKeyHex(string(trimmed)); err == nil {
+        return key, nil
+    }
+    return ParseKeyDER(data)
+}
+
+// ParseKeyPEM 解析 PEM 编码的私钥、公钥或证书(取其公钥)，只读取第一个 PEM 块
+func ParseKeyPEM(data []byte) (interface{}, error) {
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, fmt.Errorf("%w: no PEM block found", errInvalidKey)
+    }
+    return ParseKeyDER(block.Bytes)
+}
+
+// ParseKeyDER 解析 DER 编码的 PKCS#8/PKCS#1/SEC 1 私钥、PKIX/PKCS#1 公钥或 X.509 证书
+func ParseKeyDER(der []byte) (interface{}, error) {
+    if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+        return normalizeKey(key), nil
+    }
+    if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+        return normalizeKey(key), nil
+    }
+    if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+        return key, nil
+    }
+    if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+        return key, nil
+    }
+    if key, err := x509.ParseECPrivateKey(der); err == nil {
+        return key, nil
+    }
+    if cert, err := x509.ParseCertificate(der); err == nil {
+        return normalizeKey(cert.PublicKey), nil
+    }
+    return nil, fmt.Errorf("%w: unrecognized DER key", errInvalidKey)
+}
+
+// normalizeKey x509 返回的 *ed25519.PrivateKey 等指针类型统一为值类型
+func normalizeKey(key interface{}) interface{} {
+    switch k := key.(type) {
+    case *ed25519.PrivateKey:
+        return *k
+    case *ed25519.PublicKey:
+        return *k
+    }
+    return key
+}
+
+// ParseKeyHex 解析十六进制编码的 ed25519 私钥(64 字节，见 GenerateKey)或公钥(32 字节)
+func ParseKeyHex(keyHex string) (interface{}, error) {
+    raw, err := hex.DecodeString(strings.TrimSpace(keyHex))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", errInvalidKey, err)
+    }
+    switch len(raw) {
+    case ed25519.PrivateKeySize:
+        return ed25519.PrivateKey(raw), nil
+    case ed25519.PublicKeySize:
+        return ed25519.PublicKey(raw), nil
+    }
+    return nil, fmt.Errorf("%w: hex key must be %d or %d bytes, got %d", errInvalidKey, ed25519.PrivateKeySize, ed25519.PublicKeySize, len(raw))
+}
+
+// privateJWK 在 JWK 之上增加私钥参数，只用于 ParseKeyJWK 加载密钥，JWK 本身不包含私钥参数以免被发布
+type privateJWK struct {
+    JWK
+    D   string          `json:"d"`
+    P   string          `json:"p"`
+    Q   string          `json:"q"`
+    Oth json.RawMessage `json:"oth"`
+}
+
+// ParseKeyJWK 解析单个 JWK: 公钥或私钥(包含 d 时，OKP/RSA/EC)、对称密钥(oct，返回 []byte)
+func ParseKeyJWK(data []byte) (interface{}, error) {
+    var jwk privateJWK
+    if err := json.Unmarshal(data, &jwk); err != nil {
+        return nil, fmt.Errorf("%w: %v", errInvalidKey, err)
+    }
+    if jwk.Kty == "oct" {
+        secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+        if err != nil || len(secret) == 0 {
+            return nil, fmt.Errorf("%w: invalid oct key", errInvalidKey)
+        }
+        return secret, nil
+    }
+    key, _, err := jwk.PublicKey()
+    if err != nil || jwk.D == "" {
+        return key, err
+    }
+    private, err := jwk.privateKey(key)
+    if err != nil {
+        return nil, fmt.Errorf("%w: invalid %s private key %q: %v", errInvalidKey, jwk.Kty, jwk.Kid, err)
+    }
+    return private, nil
+}
+
+// privateKey 解析私钥参数，并检查与 publicKey 是否匹配
+func (j privateJWK) privateKey(publicKey interface{}) (interface{}, error) {
+    decode := base64.RawURLEncoding.DecodeString
+    d, err := decode(j.D)
+    if err != nil {
+        return nil, err
+    }
+    switch public := publicKey.(type) {
+    case ed25519.PublicKey:
+        // OKP 的 d 为 32 字节种子
+        if len(d) != ed25519.SeedSize {
+            return nil, errors.New("d must be a 32-byte seed")
+        }
+        private := ed25519.NewKeyFromSeed(d)
+        if !public.Equal(private.Public()) {
+            return nil, errors.New("d does not match x")
+        }
+        return private, nil
+    case *rsa.PublicKey:
+        // 不支持多素数 RSA，p、q 必须提供
+        if len(j.Oth) > 0 || j.P == "" || j.Q == "" {
+            return nil, errors.New("p and q are required and oth is not supported")
+        }
+        p, errP := decode(j.P)
+        q, errQ := decode(j.Q)
+        if errP != nil || errQ != nil {
+            return nil, errors.New("invalid p or q")
+        }
+        private := &rsa.PrivateKey{
+            PublicKey: *public,
+            D:         new(big.Int).SetBytes(d),
+            Primes:    []*big.Int{new(big.Int).SetBytes(p), new(big.Int).SetBytes(q)},
+        }
+        if err = private.Validate(); err != nil {
+            return nil, err
+        }
+        private.Precompute()
+        return private, nil
+    case *ecdsa.PublicKey:
+        private, err := ecdsa.ParseRawPrivateKey(public.Curve, d)
+        if err != nil {
+            return nil, err
+        }
+        if !public.Equal(&private.PublicKey) {
+            return nil, errors.New("d does not match x and y")
+        }
+        return private, nil
+    }
+    return nil, fmt.Errorf("unsupported key type %T", publicKey)
+}
+
+// publicKeySet 只有一个公钥的 KeySet，见 NewPublicKeyVerifier
+type publicKeySet struct {
+    method jwt.SigningMethod
+    public interface{}
+}
+
+// VerificationKey 检查签名算法后返回公钥，实现 KeySet
+func (p *publicKeySet) VerificationKey(token *jwt.Token) (interface{}, error) {
+    if token.Method.Alg() != p.method.Alg() {
+        return nil, errUnexpectedSigningMethod
+    }
+    return p.public, nil
+}
@@ -0,0 +1,355 @@
+package kitjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeyring_AddKeyAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kid    string
+		method jwt.SigningMethod
+		key    any
+	}{
+		{"", jwt.SigningMethodRS256, rsaKey},
+		{"", jwt.SigningMethodPS256, rsaKey},
+		{"", jwt.SigningMethodES256, ecKey},
+		{"hmac", jwt.SigningMethodHS256, []byte("0123456789abcdef0123456789abcdef")},
+	}
+	for _, tt := range tests {
+		keyring := NewKeyring()
+		if err := keyring.AddKey(tt.kid, tt.method, tt.key); err != nil {
+			t.Fatalf("%s: %v", tt.method.Alg(), err)
+		}
+		j := New(keyring, newTestClaims)
+		token := signTest(t, j, tt.method.Alg())
+		parsed, _, err := jwt.NewParser().ParseUnverified(token[len("Bearer "):], &jwt.RegisteredClaims{})
+		if err != nil || parsed.Method.Alg() != tt.method.Alg() {
+			t.Fatalf("%s: alg = %v, err = %v", tt.method.Alg(), parsed.Method.Alg(), err)
+		}
+		if claims, err := j.Parse(token); err != nil || claims.Subject != tt.method.Alg() {
+			t.Errorf("%s: claims = %v, err = %v", tt.method.Alg(), claims, err)
+		}
+	}
+	// HMAC 没有公钥指纹, 必须指定 kid
+	if err := NewKeyring().AddKey("", jwt.SigningMethodHS256, []byte("secret")); err == nil {
+		t.Error("added hmac key without kid")
+	}
+	// 曲线与算法不一致
+	if err := NewKeyring().AddKey("", jwt.SigningMethodES384, ecKey); err == nil {
+		t.Error("added P-256 key for ES384")
+	}
+	if err := NewKeyring().AddKey("", jwt.SigningMethodRS256, ecKey); err == nil {
+		t.Error("added ecdsa key for RS256")
+	}
+}
+
+func TestRSAKeyMinimumSize(t *testing.T) {
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewKeyring().AddKey("", jwt.SigningMethodRS256, weak); err == nil {
+		t.Error("added 1024-bit RSA private key")
+	}
+	if _, err := NewPublicKeyVerifier(jwt.SigningMethodPS256, &weak.PublicKey, newTestClaims); err == nil {
+		t.Error("created verifier with 1024-bit RSA public key")
+	}
+
+	// 远程 JWKS 中的短密钥被跳过
+	jwk, err := NewJWK("weak", jwt.SigningMethodRS256, &weak.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{jwk}})
+	}))
+	defer server.Close()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, &jwt.RegisteredClaims{Subject: "weak", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	token.Header["kid"] = "weak"
+	signed, err := token.SignedString(weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVerifier(NewRemoteJWKS(server.URL), newTestClaims).Parse("Bearer " + signed); !IsInvalidToken(err) {
+		t.Errorf("token signed by 1024-bit remote key: err = %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaJWK, err := NewJWK("kid", jwt.SigningMethodRS256, &rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaJSON, _ := json.Marshal(rsaJWK)
+	secret := []byte("0123456789abcdef")
+	octJSON, _ := json.Marshal(JWK{Kty: "oct", K: base64.RawURLEncoding.EncodeToString(secret)})
+
+	tests := []struct {
+		name string
+		data []byte
+		want any
+	}{
+		{"PKCS8 PEM", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), rsaKey},
+		{"PKIX PEM", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}), &ecKey.PublicKey},
+		{"PKCS1 PEM", pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}), &rsaKey.PublicKey},
+		{"ed25519 PKCS8 DER", edPKCS8, edPrivate},
+		{"EC DER", ecDER, ecKey},
+		{"hex private", []byte(hex.EncodeToString(edPrivate) + "\n"), edPrivate},
+		{"hex public", []byte(hex.EncodeToString(edPublic)), edPublic},
+		{"RSA JWK", rsaJSON, &rsaKey.PublicKey},
+		{"oct JWK", octJSON, secret},
+	}
+	for _, tt := range tests {
+		got, err := ParseKey(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %T", tt.name, got)
+		}
+	}
+	if _, err := ParseKey([]byte("not a key")); err == nil {
+		t.Error("parsed garbage")
+	}
+}
+
+func TestParseKeyJWK_Private(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherEC, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	// privateJSON 将公钥 JWK 与私钥参数合并为 JSON
+	privateJSON := func(method jwt.SigningMethod, public any, private map[string]string) []byte {
+		t.Helper()
+		jwk, err := NewJWK("kid", method, public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(jwk)
+		var members map[string]string
+		_ = json.Unmarshal(data, &members)
+		for name, value := range private {
+			members[name] = value
+		}
+		data, _ = json.Marshal(members)
+		return data
+	}
+	ecD := b64(ecKey.D.FillBytes(make([]byte, 32)))
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		data   []byte
+		want   interface{ Equal(crypto.PrivateKey) bool }
+	}{
+		{"RSA", jwt.SigningMethodRS256, privateJSON(jwt.SigningMethodRS256, &rsaKey.PublicKey, map[string]string{
+			"d": b64(rsaKey.D.Bytes()), "p": b64(rsaKey.Primes[0].Bytes()), "q": b64(rsaKey.Primes[1].Bytes()),
+		}), rsaKey},
+		{"EC", jwt.SigningMethodES256, privateJSON(jwt.SigningMethodES256, &ecKey.PublicKey, map[string]string{"d": ecD}), ecKey},
+		{"OKP", jwt.SigningMethodEdDSA, privateJSON(jwt.SigningMethodEdDSA, edKey.Public(), map[string]string{"d": b64(edKey.Seed())}), edKey},
+	}
+	for _, tt := range tests {
+		got, err := ParseKey(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !tt.want.Equal(got) {
+			t.Errorf("%s: got %T, want the original private key", tt.name, got)
+			continue
+		}
+		// 解析出的私钥可以用于签名
+		keyring := NewKeyring()
+		if err := keyring.AddKey("", tt.method, got); err != nil {
+			t.Errorf("%s: AddKey() error = %v", tt.name, err)
+			continue
+		}
+		j := New(keyring, newTestClaims)
+		if _, err := j.Parse(signTest(t, j, tt.name)); err != nil {
+			t.Errorf("%s: Parse() error = %v", tt.name, err)
+		}
+	}
+
+	invalid := map[string][]byte{
+		"EC d of another key": privateJSON(jwt.SigningMethodES256, &otherEC.PublicKey, map[string]string{"d": ecD}),
+		"OKP short d":         privateJSON(jwt.SigningMethodEdDSA, edKey.Public(), map[string]string{"d": b64(edKey.Seed()[:16])}),
+		"RSA without primes":  privateJSON(jwt.SigningMethodRS256, &rsaKey.PublicKey, map[string]string{"d": b64(rsaKey.D.Bytes())}),
+		"RSA wrong d": privateJSON(jwt.SigningMethodRS256, &rsaKey.PublicKey, map[string]string{
+			"d": b64(big.NewInt(3).Bytes()), "p": b64(rsaKey.Primes[0].Bytes()), "q": b64(rsaKey.Primes[1].Bytes()),
+		}),
+		"d not base64url": privateJSON(jwt.SigningMethodES256, &ecKey.PublicKey, map[string]string{"d": "***"}),
+	}
+	for name, data := range invalid {
+		if key, err := ParseKeyJWK(data); err == nil {
+			t.Errorf("%s: parsed %T", name, key)
+		}
+	}
+}
+
+// TestAlgorithmConfusion 用 RSA 公钥作为 HMAC 密钥伪造 token 不能通过验证
+func TestAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := NewKeyring()
+	if err = keyring.AddKey("rsa", jwt.SigningMethodRS256, rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	j := New(keyring, newTestClaims)
+	pkix, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})
+	claims := &jwt.RegisteredClaims{Subject: "admin", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	for _, header := range []map[string]any{{"kid": "rsa"}, {}} {
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		for k, v := range header {
+			forged.Header[k] = v
+		}
+		token, err := forged.SignedString(publicPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = j.Parse("Bearer " + token); !IsInvalidToken(err) {
+			t.Errorf("forged HS256 token with header %v accepted: %v", header, err)
+		}
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = j.Parse("Bearer " + none); !IsInvalidToken(err) {
+		t.Errorf("alg none accepted: %v", err)
+	}
+}
+
+func TestWithAlgorithms(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := NewKeyring()
+	if err = keyring.Add("ed", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err = keyring.AddKey("ec", jwt.SigningMethodES256, ecKey); err != nil {
+		t.Fatal(err)
+	}
+	issuer := New(keyring, newTestClaims)
+	edToken := signTest(t, issuer, "ed")
+	if err = keyring.Activate("ec"); err != nil {
+		t.Fatal(err)
+	}
+	ecToken := signTest(t, issuer, "ec")
+
+	strict := New(keyring, newTestClaims, WithAlgorithms("ES256"))
+	if _, err = strict.Parse(ecToken); err != nil {
+		t.Errorf("ES256 rejected: %v", err)
+	}
+	if _, err = strict.Parse(edToken); !IsInvalidToken(err) {
+		t.Errorf("EdDSA accepted: %v", err)
+	}
+	// 调用方传入的 WithValidMethods 不能放宽限制
+	if _, err = strict.Parse(edToken, jwt.WithValidMethods([]string{"EdDSA"})); !IsInvalidToken(err) {
+		t.Errorf("EdDSA accepted with parser option: %v", err)
+	}
+	if err = keyring.Activate("ed"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = strict.Sign(newTestClaims()); err == nil {
+		t.Error("signed with disallowed algorithm")
+	}
+}
+
+func TestNewPublicKeyVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := NewKeyring()
+	if err = keyring.AddKey("idp-1", jwt.SigningMethodRS256, rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	token := signTest(t, New(keyring, newTestClaims), "user")
+
+	verifier, err := NewPublicKeyVerifier(jwt.SigningMethodRS256, &rsaKey.PublicKey, newTestClaims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := verifier.Parse(token); err != nil || claims.Subject != "user" {
+		t.Errorf("claims = %v, err = %v", claims, err)
+	}
+	if _, err = verifier.Sign(newTestClaims()); err == nil {
+		t.Error("verifier signed a token")
+	}
+	if _, err = NewPublicKeyVerifier(jwt.SigningMethodHS256, []byte("secret"), newTestClaims); err == nil {
+		t.Error("created hmac verifier")
+	}
+	if _, err = NewPublicKeyVerifier(jwt.SigningMethodES256, &rsaKey.PublicKey, newTestClaims); err == nil {
+		t.Error("created verifier with mismatched key")
+	}
+}
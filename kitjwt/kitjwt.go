@@ -1,14 +1,12 @@
 package kitjwt
 
 import (
-    "crypto"
     "crypto/ed25519"
     "crypto/rand"
     "encoding/hex"
     "errors"
     "fmt"
     "strings"
-    "sync"
 
     "github.com/golang-jwt/jwt/v5"
 )
@@ -18,6 +16,8 @@ var (
     errInvalidToken = errors.New("token invalid")
     // errUnexpectedSigningMethod 表示签名方法不匹配
     errUnexpectedSigningMethod = errors.New("unexpected signing method")
+    // errVerifyOnly 表示实例只能验证 token
+    errVerifyOnly = errors.New("jwt instance is verify-only")
 )
 
 // IsInvalidToken 判断错误是否为 token 无效错误
@@ -30,7 +30,7 @@ func IsUnexpectedSigningMethod(err error) bool {
     return errors.Is(err, errUnexpectedSigningMethod)
 }
 
-// JWT 是一个通用的 JWT 工具接口，支持 ed25519 签名算法
+// JWT 是一个通用的 JWT 工具接口，支持 EdDSA、RSA、ECDSA、HMAC 签名算法与密钥轮换
 type JWT[T jwt.Claims] interface {
     // Sign 对 claims 进行签名，返回 JWT token 字符串
     Sign(claims T) (string, error)
@@ -39,39 +39,116 @@ type JWT[T jwt.Claims] interface {
     Parse(token string, opts ...jwt.ParserOption) (T, error)
 }
 
-// jwtImpl 是一个通用的 JWT 工具实现，支持 ed25519 签名算法
+// KeySet 按 token 选择验证密钥，签名与 jwt.Keyfunc 相同; Keyring 与 RemoteJWKS 实现了该接口
+type KeySet interface {
+    VerificationKey(token *jwt.Token) (interface{}, error)
+}
+
+// Option 配置 JWT 实例
+type Option func(*options)
+
+type options struct {
+    algorithms []string
+}
+
+// WithAlgorithms 设置实例允许的签名算法，如 "RS256"、"ES256"，其他算法的 token 一律拒绝，也不能用其他算法签名.
+// 未设置时只接受 KeySet 中密钥对应的算法; 每个密钥只绑定一种算法，因此不会出现 alg 混淆(如用 RSA 公钥验证 HS256)
+func WithAlgorithms(algorithms ...string) Option {
+    return func(o *options) {
+        o.algorithms = algorithms
+    }
+}
+
+func (o options) allowed(alg string) bool {
+    if len(o.algorithms) == 0 {
+        return true
+    }
+    for _, algorithm := range o.algorithms {
+        if algorithm == alg {
+            return true
+        }
+    }
+    return false
+}
+
+func newOptions(opts []Option) options {
+    var o options
+    for _, opt := range opts {
+        opt(&o)
+    }
+    return o
+}
+
+// jwtImpl 是一个通用的 JWT 工具实现，使用 Keyring 中的密钥签名，使用 KeySet 验证
 type jwtImpl[T jwt.Claims] struct {
-    key       ed25519.PrivateKey
-    publicKey crypto.PublicKey
-    algorithm jwt.SigningMethod
+    keyring   *Keyring // 为 nil 时只能验证
+    keys      KeySet
     newClaims func() T // 用于创建新的 claims 实例
+    options   options
 }
 
-// create 创建一个新的 JWT 实例
-// keyHex: 十六进制编码的 ed25519 私钥（64字节，即128个十六进制字符）
+// New 使用 keyring 创建 JWT 实例，keyring 中的密钥可以在运行时添加、轮换与退役
 // newClaims: 用于创建新的 claims 实例的函数
-func create[T jwt.Claims](keyHex string, newClaims func() T) (*jwtImpl[T], error) {
-    bytes, err := hex.DecodeString(keyHex)
+func New[T jwt.Claims](keyring *Keyring, newClaims func() T, opts ...Option) JWT[T] {
+    return &jwtImpl[T]{keyring: keyring, keys: keyring, newClaims: newClaims, options: newOptions(opts)}
+}
+
+// NewVerifier 创建只能验证的 JWT 实例，Sign 总是返回错误
+// 示例:
+//
+//	verifier := kitjwt.NewVerifier(kitjwt.NewRemoteJWKS("https://auth.example.com/.well-known/jwks.json"), func() *types.Claims {
+//	    return &types.Claims{}
+//	})
+func NewVerifier[T jwt.Claims](keys KeySet, newClaims func() T, opts ...Option) JWT[T] {
+    return &jwtImpl[T]{keys: keys, newClaims: newClaims, options: newOptions(opts)}
+}
+
+// NewPublicKeyVerifier 创建只持有一个公钥的验证实例，只接受 method 对应的算法，忽略 token 头部的 kid
+// 传入私钥时只保留其公钥; HMAC 没有公钥，需要使用 Keyring.AddKey
+// 示例:
+//
+//	key, err := kitjwt.ParseKey(idpPublicKeyPEM)
+//	verifier, err := kitjwt.NewPublicKeyVerifier(jwt.SigningMethodRS256, key, func() *types.Claims {
+//	    return &types.Claims{}
+//	})
+func NewPublicKeyVerifier[T jwt.Claims](method jwt.SigningMethod, publicKey interface{}, newClaims func() T, opts ...Option) (JWT[T], error) {
+    _, public, err := checkKey(method, publicKey)
     if err != nil {
-        return nil, fmt.Errorf("failed to decode jwt key: %w", err)
+        return nil, err
     }
-    if len(bytes) != ed25519.PrivateKeySize {
-        return nil, fmt.Errorf("jwt key length incorrect: expected %d bytes, got %d", ed25519.PrivateKeySize, len(bytes))
+    if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+        return nil, fmt.Errorf("%w: %s has no public key", errInvalidKey, method.Alg())
     }
+    keys := &publicKeySet{method: method, public: public}
+    return NewVerifier[T](keys, newClaims, append([]Option{WithAlgorithms(method.Alg())}, opts...)...), nil
+}
 
-    privateKey := ed25519.PrivateKey(bytes)
-    return &jwtImpl[T]{
-        key:       privateKey,
-        publicKey: privateKey.Public(),
-        algorithm: jwt.SigningMethodEdDSA,
-        newClaims: newClaims,
-    }, nil
+// create 使用单个密钥创建一个新的 JWT 实例
+// keyHex: 十六进制编码的 ed25519 私钥（64字节，即128个十六进制字符）
+// newClaims: 用于创建新的 claims 实例的函数
+func create[T jwt.Claims](keyHex string, newClaims func() T) (*jwtImpl[T], error) {
+    keyring := NewKeyring()
+    if err := keyring.Add("", keyHex); err != nil {
+        return nil, err
+    }
+    return &jwtImpl[T]{keyring: keyring, keys: keyring, newClaims: newClaims}, nil
 }
 
 // Sign 对 claims 进行签名，返回 JWT token 字符串
 func (j *jwtImpl[T]) Sign(claims T) (string, error) {
-    token := jwt.NewWithClaims(j.algorithm, claims)
-    return token.SignedString(j.key)
+    if j.keyring == nil {
+        return "", errVerifyOnly
+    }
+    kid, key, err := j.keyring.signingKey()
+    if err != nil {
+        return "", err
+    }
+    if !j.options.allowed(key.method.Alg()) {
+        return "", fmt.Errorf("%w: %s", errUnexpectedSigningMethod, key.method.Alg())
+    }
+    token := jwt.NewWithClaims(key.method, claims)
+    token.Header["kid"] = kid
+    return token.SignedString(key.private)
 }
 
 // Parse 解析并验证 JWT token，返回 Claims
@@ -88,17 +165,11 @@ func (j *jwtImpl[T]) Parse(token string, opts ...jwt.ParserOption) (T, error) {
     }
 
     claims := j.newClaims()
-    parsed, err := jwt.ParseWithClaims(
-        token[7:],
-        claims,
-        func(token *jwt.Token) (interface{}, error) {
-            if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
-                return nil, errUnexpectedSigningMethod
-            }
-            return j.publicKey, nil
-        },
-        opts...,
-    )
+    if len(j.options.algorithms) > 0 {
+        // 放在最后，调用方传入的 jwt.WithValidMethods 不能放宽限制
+        opts = append(opts[:len(opts):len(opts)], jwt.WithValidMethods(j.options.algorithms))
+    }
+    parsed, err := jwt.ParseWithClaims(token[7:], claims, j.keys.VerificationKey, opts...)
 
     if err != nil {
         return zero, errInvalidToken
@@ -111,44 +182,6 @@ func (j *jwtImpl[T]) Parse(token string, opts ...jwt.ParserOption) (T, error) {
     return claims, nil
 }
 
-var (
-    instance any
-    once     sync.Once
-    initErr  error
-)
-
-// Bootstrap 初始化 JWT 实例（泛型版本）
-// keyHex: 十六进制编码的 ed25519 私钥（64字节，即128个十六进制字符）
-// newClaims: 用于创建新的 claims 实例的函数
-// 示例:
-//
-//	kitjwt.Bootstrap("your-key-hex", func() *types.JwtAdminClaims {
-//	    return &types.JwtAdminClaims{}
-//	})
-func Bootstrap[T jwt.Claims](keyHex string, newClaims func() T) error {
-    if instance != nil {
-        panic("JWT instance already initialized")
-    }
-    once.Do(func() {
-        var impl *jwtImpl[T]
-        impl, initErr = create(keyHex, newClaims)
-        instance = impl
-    })
-    return initErr
-}
-
-// Get 获取 JWT 实例（单例模式）
-// 必须先调用 Bootstrap 初始化
-// 示例:
-//
-//	claims, err := kitjwt.Get[*types.JwtAdminClaims]().Parse(token, jwt.WithExpirationRequired())
-func Get[T jwt.Claims]() JWT[T] {
-    if instance == nil {
-        panic("JWT instance not initialized. Call Bootstrap(keyHex, newClaims) first.")
-    }
-    return instance.(JWT[T])
-}
-
 // GenerateKey 生成一个新的 ed25519 私钥，并以十六进制字符串形式返回
 func GenerateKey() (string, error) {
     _, privateKey, err := ed25519.GenerateKey(rand.Reader)
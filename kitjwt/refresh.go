@@ -0,0 +1,231 @@
+package kitjwt
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultRefreshTTL 默认的 refresh token 有效期
+const DefaultRefreshTTL = 30 * 24 * time.Hour
+
+var (
+    // errInvalidRefreshToken 表示 refresh token 无效、已过期或不存在
+    errInvalidRefreshToken = errors.New("refresh token invalid")
+    // errRefreshTokenReused 表示已轮换的 refresh token 被再次使用，整个 family 已被撤销
+    errRefreshTokenReused = fmt.Errorf("%w: reused, token family revoked", errInvalidRefreshToken)
+    // errRefreshTokenRevoked 表示 refresh token 所在的 family 已被撤销
+    errRefreshTokenRevoked = fmt.Errorf("%w: token family revoked", errInvalidRefreshToken)
+)
+
+// IsInvalidRefreshToken 判断错误是否为 refresh token 无效错误，包括重复使用与已撤销
+func IsInvalidRefreshToken(err error) bool {
+    return errors.Is(err, errInvalidRefreshToken)
+}
+
+// IsRefreshTokenReused 判断错误是否为 refresh token 重复使用错误
+func IsRefreshTokenReused(err error) bool {
+    return errors.Is(err, errRefreshTokenReused)
+}
+
+// RefreshToken 存储中的 refresh token 记录
+type RefreshToken struct {
+    // ID token 标识: opaque token 为 token 的 SHA-256，JWT token 为 jti; 存储中不保存 token 本身
+    ID string
+    // FamilyID 同一次登录轮换出的所有 token 属于同一个 family
+    FamilyID  string
+    Subject   string
+    IssuedAt  time.Time
+    ExpiresAt time.Time
+    // UsedAt 轮换时间，非零表示 token 已被使用
+    UsedAt time.Time
+    // Revoked 所在的 family 已被撤销
+    Revoked bool
+}
+
+// TokenStore 保存 refresh token 记录，实现需要并发安全
+type TokenStore interface {
+    // Save 保存新签发的 token
+    Save(ctx context.Context, token RefreshToken) error
+    // Get 获取 id 对应的记录，不存在时 ok 为 false;
+    // family 已撤销时 Revoked 为 true，包括撤销之后才保存的 token
+    Get(ctx context.Context, id string) (token RefreshToken, ok bool, err error)
+    // MarkUsed 将 token 标记为已使用，必须是原子操作; token 已被使用或不存在时 ok 为 false
+    MarkUsed(ctx context.Context, id string, usedAt time.Time) (ok bool, err error)
+    // RevokeFamily 撤销 family 中的所有 token
+    RevokeFamily(ctx context.Context, familyID string) error
+}
+
+// RefreshOption 配置 RefreshTokenManager
+type RefreshOption func(*RefreshTokenManager)
+
+// WithRefreshTTL 设置 refresh token 有效期，每次轮换后重新计算，默认为 DefaultRefreshTTL
+func WithRefreshTTL(ttl time.Duration) RefreshOption {
+    return func(m *RefreshTokenManager) {
+        m.ttl = ttl
+    }
+}
+
+// WithRefreshJWT 签发 JWT 格式的 refresh token，默认为随机生成的 opaque token.
+// 建议使用与 access token 不同的密钥，避免 access token 被当作 refresh token 提交
+func WithRefreshJWT(j JWT[*jwt.RegisteredClaims]) RefreshOption {
+    return func(m *RefreshTokenManager) {
+        m.jwt = j
+    }
+}
+
+// RefreshTokenManager 签发与轮换 refresh token
+//   - 每次 Rotate 都会使旧 token 失效并签发同一 family 的新 token
+//   - 已轮换的旧 token 被再次使用时，视为 token 泄露，撤销整个 family
+//
+// 同一个 token 并发刷新时只有一个请求成功，其余请求会撤销 family，客户端需要串行刷新
+type RefreshTokenManager struct {
+    store TokenStore
+    ttl   time.Duration
+    jwt   JWT[*jwt.RegisteredClaims]
+    now   func() time.Time
+}
+
+// NewRefreshTokenManager 创建 refresh token 管理器
+// 示例:
+//
+//	refresh := kitjwt.NewRefreshTokenManager(kitjwt.NewMemoryTokenStore())
+//	// 登录
+//	refreshToken, err := refresh.Issue(ctx, userID)
+//	// 刷新: 使用新 token 替换客户端保存的旧 token，并为 record.Subject 签发新的 access token
+//	refreshToken, record, err := refresh.Rotate(ctx, refreshToken)
+func NewRefreshTokenManager(store TokenStore, opts ...RefreshOption) *RefreshTokenManager {
+    m := &RefreshTokenManager{store: store, ttl: DefaultRefreshTTL, now: time.Now}
+    for _, opt := range opts {
+        opt(m)
+    }
+    return m
+}
+
+// randomID 生成 32 字节的随机标识
+func randomID() (string, error) {
+    buf := make([]byte, 32)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashOpaque 计算 opaque token 在存储中的 ID
+func hashOpaque(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Issue 为 subject 签发新 family 的 refresh token，用于登录
+func (m *RefreshTokenManager) Issue(ctx context.Context, subject string) (string, error) {
+    familyID, err := randomID()
+    if err != nil {
+        return "", err
+    }
+    token, _, err := m.issue(ctx, familyID, subject)
+    return token, err
+}
+
+// issue 签发 family 中的 token 并保存
+func (m *RefreshTokenManager) issue(ctx context.Context, familyID, subject string) (string, RefreshToken, error) {
+    now := m.now()
+    random, err := randomID()
+    if err != nil {
+        return "", RefreshToken{}, err
+    }
+    record := RefreshToken{FamilyID: familyID, Subject: subject, IssuedAt: now, ExpiresAt: now.Add(m.ttl)}
+    token := random
+    if m.jwt == nil {
+        record.ID = hashOpaque(token)
+    } else {
+        record.ID = random
+        token, err = m.jwt.Sign(&jwt.RegisteredClaims{
+            ID:        random,
+            Subject:   subject,
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+        })
+        if err != nil {
+            return "", RefreshToken{}, err
+        }
+    }
+    if err = m.store.Save(ctx, record); err != nil {
+        return "", RefreshToken{}, err
+    }
+    return token, record, nil
+}
+
+// tokenID 验证 token 格式(JWT 时验证签名)并返回存储中的 ID
+func (m *RefreshTokenManager) tokenID(token string) (string, error) {
+    if token == "" {
+        return "", errInvalidRefreshToken
+    }
+    if m.jwt == nil {
+        return hashOpaque(token), nil
+    }
+    claims, err := m.jwt.Parse("Bearer "+token, jwt.WithExpirationRequired(), jwt.WithTimeFunc(m.now))
+    if err != nil || claims.ID == "" {
+        return "", errInvalidRefreshToken
+    }
+    return claims.ID, nil
+}
+
+// lookup 查找有效的 token 记录，不检查是否已使用
+func (m *RefreshTokenManager) lookup(ctx context.Context, token string) (RefreshToken, error) {
+    id, err := m.tokenID(token)
+    if err != nil {
+        return RefreshToken{}, err
+    }
+    record, ok, err := m.store.Get(ctx, id)
+    if err != nil {
+        return RefreshToken{}, err
+    }
+    if !ok || !m.now().Before(record.ExpiresAt) {
+        return RefreshToken{}, errInvalidRefreshToken
+    }
+    if record.Revoked {
+        return RefreshToken{}, errRefreshTokenRevoked
+    }
+    return record, nil
+}
+
+// Rotate 使用 refresh token 换取同一 family 的新 token，旧 token 随即失效; record 为新 token 的记录.
+// 旧 token 已被使用过时撤销整个 family 并返回错误，见 IsRefreshTokenReused
+func (m *RefreshTokenManager) Rotate(ctx context.Context, token string) (string, RefreshToken, error) {
+    record, err := m.lookup(ctx, token)
+    if err != nil {
+        return "", RefreshToken{}, err
+    }
+    marked := false
+    if record.UsedAt.IsZero() {
+        if marked, err = m.store.MarkUsed(ctx, record.ID, m.now()); err != nil {
+            return "", RefreshToken{}, err
+        }
+    }
+    if !marked {
+        if err = m.store.RevokeFamily(ctx, record.FamilyID); err != nil {
+            return "", RefreshToken{}, err
+        }
+        return "", RefreshToken{}, errRefreshTokenReused
+    }
+    return m.issue(ctx, record.FamilyID, record.Subject)
+}
+
+// Revoke 撤销 token 所在的整个 family，用于退出登录; token 已失效时不返回错误
+func (m *RefreshTokenManager) Revoke(ctx context.Context, token string) error {
+    record, err := m.lookup(ctx, token)
+    if err != nil {
+        if IsInvalidRefreshToken(err) {
+            return nil
+        }
+        return err
+    }
+    return m.store.RevokeFamily(ctx, record.FamilyID)
+}
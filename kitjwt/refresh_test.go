@@ -0,0 +1,172 @@
+package kitjwt
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestRefresh 创建时间可控的 RefreshTokenManager
+func newTestRefresh(t *testing.T, opts ...RefreshOption) (*RefreshTokenManager, *MemoryTokenStore, *time.Time) {
+	t.Helper()
+	store := NewMemoryTokenStore()
+	m := NewRefreshTokenManager(store, append([]RefreshOption{WithRefreshTTL(time.Hour)}, opts...)...)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	return m, store, &now
+}
+
+func newTestRefreshJWT(t *testing.T) RefreshOption {
+	t.Helper()
+	keyring := NewKeyring()
+	if err := keyring.Add("refresh", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	return WithRefreshJWT(New(keyring, newTestClaims))
+}
+
+func TestRefreshTokenManager_Rotate(t *testing.T) {
+	for _, format := range []string{"opaque", "jwt"} {
+		var opts []RefreshOption
+		if format == "jwt" {
+			opts = append(opts, newTestRefreshJWT(t))
+		}
+		m, store, _ := newTestRefresh(t, opts...)
+		ctx := context.Background()
+
+		first, err := m.Issue(ctx, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, record, err := m.Rotate(ctx, first)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if second == first || record.Subject != "user-1" || record.FamilyID == "" {
+			t.Errorf("%s: record = %+v", format, record)
+		}
+		// 存储中不保存 token 本身
+		if _, ok, _ := store.Get(ctx, first); ok {
+			t.Errorf("%s: token stored in plain text", format)
+		}
+		third, next, err := m.Rotate(ctx, second)
+		if err != nil || next.FamilyID != record.FamilyID {
+			t.Fatalf("%s: family = %q, err = %v", format, next.FamilyID, err)
+		}
+
+		// 重复使用已轮换的 token 撤销整个 family, 包括最新的 token
+		if _, _, err = m.Rotate(ctx, first); !IsRefreshTokenReused(err) {
+			t.Errorf("%s: reuse: err = %v", format, err)
+		}
+		if _, _, err = m.Rotate(ctx, third); !IsInvalidRefreshToken(err) || IsRefreshTokenReused(err) {
+			t.Errorf("%s: latest token after reuse: err = %v", format, err)
+		}
+
+		// 其他 family 不受影响
+		other, err := m.Issue(ctx, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err = m.Rotate(ctx, other); err != nil {
+			t.Errorf("%s: other family: %v", format, err)
+		}
+	}
+}
+
+func TestRefreshTokenManager_Invalid(t *testing.T) {
+	m, _, now := newTestRefresh(t)
+	jwtManager, _, _ := newTestRefresh(t, newTestRefreshJWT(t))
+	ctx := context.Background()
+	for _, token := range []string{"", "unknown"} {
+		if _, _, err := m.Rotate(ctx, token); !IsInvalidRefreshToken(err) {
+			t.Errorf("%q: err = %v", token, err)
+		}
+		if _, _, err := jwtManager.Rotate(ctx, token); !IsInvalidRefreshToken(err) {
+			t.Errorf("jwt %q: err = %v", token, err)
+		}
+	}
+	// opaque token 不能用于 JWT 格式, 反之亦然
+	opaque, err := m.Issue(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = jwtManager.Rotate(ctx, opaque); !IsInvalidRefreshToken(err) {
+		t.Errorf("opaque token accepted by jwt manager: %v", err)
+	}
+
+	*now = now.Add(time.Hour)
+	if _, _, err = m.Rotate(ctx, opaque); !IsInvalidRefreshToken(err) {
+		t.Errorf("expired token: err = %v", err)
+	}
+}
+
+func TestRefreshTokenManager_Revoke(t *testing.T) {
+	m, _, _ := newTestRefresh(t)
+	ctx := context.Background()
+	token, err := m.Issue(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, _, err := m.Rotate(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Revoke(ctx, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.Rotate(ctx, rotated); !IsInvalidRefreshToken(err) {
+		t.Errorf("revoked token: err = %v", err)
+	}
+	if err = m.Revoke(ctx, "unknown"); err != nil {
+		t.Errorf("revoke unknown token: %v", err)
+	}
+}
+
+// TestRefreshTokenManager_ConcurrentRotate 同一个 token 并发刷新时只有一个请求成功
+func TestRefreshTokenManager_ConcurrentRotate(t *testing.T) {
+	m, _, _ := newTestRefresh(t)
+	ctx := context.Background()
+	token, err := m.Issue(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var succeeded atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := m.Rotate(ctx, token); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded.Load() != 1 {
+		t.Errorf("succeeded = %d", succeeded.Load())
+	}
+}
+
+func TestMemoryTokenStore_DeleteExpired(t *testing.T) {
+	m, store, now := newTestRefresh(t)
+	ctx := context.Background()
+	token, err := m.Issue(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.Rotate(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(30 * time.Minute)
+	if _, err = m.Issue(ctx, "user-2"); err != nil {
+		t.Fatal(err)
+	}
+	if deleted := store.DeleteExpired(now.Add(31 * time.Minute)); deleted != 2 {
+		t.Errorf("deleted = %d", deleted)
+	}
+	if len(store.tokens) != 1 || len(store.families) != 1 {
+		t.Errorf("tokens = %d, families = %d", len(store.tokens), len(store.families))
+	}
+}
@@ -0,0 +1,142 @@
+package kitjwt
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultName Bootstrap、BootstrapKeyring 与 Get 使用的实例名称
+const DefaultName = "default"
+
+var (
+    // errNotRegistered 表示没有注册对应名称的实例
+    errNotRegistered = errors.New("jwt instance not registered")
+    // errClaimsTypeMismatch 表示实例的 claims 类型与调用方不一致
+    errClaimsTypeMismatch = errors.New("jwt claims type mismatch")
+)
+
+// IsNotRegistered 判断错误是否为实例未注册错误
+func IsNotRegistered(err error) bool {
+    return errors.Is(err, errNotRegistered)
+}
+
+// IsClaimsTypeMismatch 判断错误是否为 claims 类型不匹配错误
+func IsClaimsTypeMismatch(err error) bool {
+    return errors.Is(err, errClaimsTypeMismatch)
+}
+
+var (
+    registryMu sync.RWMutex
+    registry   = make(map[string]any)
+)
+
+// Register 以 name 注册 JWT 实例，同一进程可以注册多个 claims 类型不同的实例; name 已存在时返回错误
+// 示例:
+//
+//	adminKeyring := kitjwt.NewKeyring()
+//	_ = adminKeyring.Add("", adminKeyHex)
+//	_ = kitjwt.Register("admin", kitjwt.New(adminKeyring, func() *types.JwtAdminClaims {
+//	    return &types.JwtAdminClaims{}
+//	}))
+//	_ = kitjwt.Register("member", kitjwt.New(memberKeyring, func() *types.JwtMemberClaims {
+//	    return &types.JwtMemberClaims{}
+//	}))
+func Register[T jwt.Claims](name string, j JWT[T]) error {
+    registryMu.Lock()
+    defer registryMu.Unlock()
+    if _, ok := registry[name]; ok {
+        return fmt.Errorf("jwt instance %q already registered", name)
+    }
+    registry[name] = j
+    return nil
+}
+
+// Unregister 移除 name 对应的实例
+func Unregister(name string) {
+    registryMu.Lock()
+    defer registryMu.Unlock()
+    delete(registry, name)
+}
+
+// Lookup 获取 name 对应的实例，未注册或 claims 类型不是 T 时返回错误
+// 示例:
+//
+//	admin, err := kitjwt.Lookup[*types.JwtAdminClaims]("admin")
+func Lookup[T jwt.Claims](name string) (JWT[T], error) {
+    registryMu.RLock()
+    instance, ok := registry[name]
+    registryMu.RUnlock()
+    if !ok {
+        return nil, fmt.Errorf("%w: %q", errNotRegistered, name)
+    }
+    j, ok := instance.(JWT[T])
+    if !ok {
+        var zero T
+        return nil, fmt.Errorf("%w: instance %q is %T, not JWT[%T]", errClaimsTypeMismatch, name, instance, zero)
+    }
+    return j, nil
+}
+
+// Bootstrap 以 DefaultName 注册 JWT 实例（泛型版本），需要轮换密钥时使用 BootstrapKeyring，需要多个实例时使用 Register
+// keyHex: 十六进制编码的 ed25519 私钥（64字节，即128个十六进制字符）
+// newClaims: 用于创建新的 claims 实例的函数
+// 示例:
+//
+//	kitjwt.Bootstrap("your-key-hex", func() *types.JwtAdminClaims {
+//	    return &types.JwtAdminClaims{}
+//	})
+func Bootstrap[T jwt.Claims](keyHex string, newClaims func() T) error {
+    impl, err := create(keyHex, newClaims)
+    if err != nil {
+        return err
+    }
+    if err = Register[T](DefaultName, impl); err != nil {
+        panic("JWT instance already initialized")
+    }
+    return nil
+}
+
+// BootstrapKeyring 使用 keyring 以 DefaultName 注册 JWT 实例，之后可以直接在 keyring 上轮换密钥
+// 示例:
+//
+//	keyring := kitjwt.NewKeyring()
+//	_ = keyring.Add("2024-01", keyHex)
+//	err := kitjwt.BootstrapKeyring(keyring, func() *types.JwtAdminClaims {
+//	    return &types.JwtAdminClaims{}
+//	})
+func BootstrapKeyring[T jwt.Claims](keyring *Keyring, newClaims func() T) error {
+    if err := Register(DefaultName, New(keyring, newClaims)); err != nil {
+        panic("JWT instance already initialized")
+    }
+    return nil
+}
+
+// Get 获取 DefaultName 对应的实例，必须先调用 Bootstrap 初始化
+// 未初始化或 claims 类型不是 T 时不会 panic，返回的实例 Sign 与 Parse 总是返回该错误; 需要直接处理错误时使用 Lookup
+// 示例:
+//
+//	claims, err := kitjwt.Get[*types.JwtAdminClaims]().Parse(token, jwt.WithExpirationRequired())
+func Get[T jwt.Claims]() JWT[T] {
+    j, err := Lookup[T](DefaultName)
+    if err != nil {
+        return failedJWT[T]{err: err}
+    }
+    return j
+}
+
+// failedJWT Get 查找失败时返回的实例
+type failedJWT[T jwt.Claims] struct {
+    err error
+}
+
+func (f failedJWT[T]) Sign(T) (string, error) {
+    return "", f.err
+}
+
+func (f failedJWT[T]) Parse(string, ...jwt.ParserOption) (T, error) {
+    var zero T
+    return zero, f.err
+}
@@ -0,0 +1,70 @@
+package kitjwt
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testAdminClaims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role"`
+}
+
+func TestRegistry(t *testing.T) {
+	keyring := NewKeyring()
+	if err := keyring.Add("", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register("test.member", New(keyring, newTestClaims)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Unregister("test.member") })
+	adminKeyring := NewKeyring()
+	if err := adminKeyring.Add("", newTestKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register("test.admin", New(adminKeyring, func() *testAdminClaims { return &testAdminClaims{} })); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Unregister("test.admin") })
+
+	if err := Register("test.admin", New(adminKeyring, newTestClaims)); err == nil {
+		t.Error("registered duplicate name")
+	}
+	member, err := Lookup[*jwt.RegisteredClaims]("test.member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := Lookup[*testAdminClaims]("test.admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := admin.Sign(&testAdminClaims{Role: "root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := admin.Parse("Bearer " + token); err != nil || claims.Role != "root" {
+		t.Errorf("claims = %v, err = %v", claims, err)
+	}
+	if _, err = member.Parse("Bearer " + token); !IsInvalidToken(err) {
+		t.Errorf("member accepted admin token: %v", err)
+	}
+
+	if _, err = Lookup[*jwt.RegisteredClaims]("test.admin"); !IsClaimsTypeMismatch(err) {
+		t.Errorf("mismatch: err = %v", err)
+	}
+	if _, err = Lookup[*jwt.RegisteredClaims]("test.missing"); !IsNotRegistered(err) {
+		t.Errorf("missing: err = %v", err)
+	}
+}
+
+func TestGet_NotInitialized(t *testing.T) {
+	// 未初始化时不会 panic
+	if _, err := Get[*jwt.RegisteredClaims]().Parse("Bearer x"); !IsNotRegistered(err) {
+		t.Errorf("parse: err = %v", err)
+	}
+	if _, err := Get[*jwt.RegisteredClaims]().Sign(newTestClaims()); !IsNotRegistered(err) {
+		t.Errorf("sign: err = %v", err)
+	}
+}
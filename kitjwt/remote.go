@@ -0,0 +1,208 @@
+package kitjwt
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    // DefaultJWKSRefreshInterval 默认的 JWKS 缓存时间
+    DefaultJWKSRefreshInterval = time.Hour
+    // DefaultJWKSMinRefreshInterval 默认的两次拉取 JWKS 之间的最小间隔
+    DefaultJWKSMinRefreshInterval = time.Minute
+    // DefaultJWKSRefreshTimeout 默认的验证时拉取 JWKS 的超时时间
+    DefaultJWKSRefreshTimeout = 5 * time.Second
+    // maxJWKSBytes JWKS 响应的最大长度
+    maxJWKSBytes = 1 << 20
+)
+
+// RemoteJWKSOption 配置 RemoteJWKS
+type RemoteJWKSOption func(*RemoteJWKS)
+
+// WithJWKSHTTPClient 设置拉取 JWKS 使用的 http.Client，默认为超时 10 秒的客户端
+func WithJWKSHTTPClient(client *http.Client) RemoteJWKSOption {
+    return func(r *RemoteJWKS) {
+        r.client = client
+    }
+}
+
+// WithJWKSRefreshInterval 设置 JWKS 缓存时间，过期后在下次验证时重新拉取
+func WithJWKSRefreshInterval(interval time.Duration) RemoteJWKSOption {
+    return func(r *RemoteJWKS) {
+        r.refreshInterval = interval
+    }
+}
+
+// WithJWKSMinRefreshInterval 设置两次拉取之间的最小间隔，防止携带未知 kid 的 token 频繁触发拉取
+func WithJWKSMinRefreshInterval(interval time.Duration) RemoteJWKSOption {
+    return func(r *RemoteJWKS) {
+        r.minRefreshInterval = interval
+    }
+}
+
+// WithJWKSRefreshTimeout 设置验证 token 时拉取 JWKS 的超时时间，避免远程响应缓慢时阻塞验证;
+// 超时后继续使用已缓存的密钥
+func WithJWKSRefreshTimeout(timeout time.Duration) RemoteJWKSOption {
+    return func(r *RemoteJWKS) {
+        r.refreshTimeout = timeout
+    }
+}
+
+// remoteKey 远程 JWKS 中的一个验证密钥
+type remoteKey struct {
+    method jwt.SigningMethod
+    public interface{}
+}
+
+// RemoteJWKS 拉取并缓存远程 JWKS，实现 KeySet，配合 NewVerifier 验证其他服务签发的 token
+//   - 缓存过期或遇到未知 kid 时重新拉取，两次拉取之间至少间隔 minRefreshInterval
+//   - 拉取失败或超过 refreshTimeout 时继续使用已缓存的密钥
+type RemoteJWKS struct {
+    url                string
+    client             *http.Client
+    refreshInterval    time.Duration
+    minRefreshInterval time.Duration
+    refreshTimeout     time.Duration
+    now                func() time.Time
+
+    mu          sync.RWMutex
+    keys        map[string]remoteKey
+    fetchedAt   time.Time
+    attemptedAt time.Time
+    // refreshMu 保证同一时间只有一个请求在拉取
+    refreshMu sync.Mutex
+}
+
+// NewRemoteJWKS 创建远程 JWKS，首次验证时拉取; 可以调用 Refresh 预先拉取
+func NewRemoteJWKS(url string, opts ...RemoteJWKSOption) *RemoteJWKS {
+    r := &RemoteJWKS{
+        url:                url,
+        client:             &http.Client{Timeout: 10 * time.Second},
+        refreshInterval:    DefaultJWKSRefreshInterval,
+        minRefreshInterval: DefaultJWKSMinRefreshInterval,
+        refreshTimeout:     DefaultJWKSRefreshTimeout,
+        now:                time.Now,
+        keys:               make(map[string]remoteKey),
+    }
+    for _, opt := range opts {
+        opt(r)
+    }
+    return r
+}
+
+// Refresh 立即拉取 JWKS，不受最小间隔限制
+func (r *RemoteJWKS) Refresh(ctx context.Context) error {
+    r.refreshMu.Lock()
+    defer r.refreshMu.Unlock()
+    return r.fetch(ctx)
+}
+
+// fetch 拉取并替换缓存的密钥，调用方需持有 refreshMu
+func (r *RemoteJWKS) fetch(ctx context.Context) error {
+    r.mu.Lock()
+    r.attemptedAt = r.now()
+    r.mu.Unlock()
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/jwk-set+json, application/json")
+    resp, err := r.client.Do(req)
+    if err != nil {
+        return fmt.Errorf("fetch jwks: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("fetch jwks: unexpected status %s", resp.Status)
+    }
+    var set JWKSet
+    if err = json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+        return fmt.Errorf("decode jwks: %w", err)
+    }
+    keys := make(map[string]remoteKey, len(set.Keys))
+    for _, jwk := range set.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        // 跳过不支持的密钥，不影响其余密钥
+        publicKey, method, keyErr := jwk.PublicKey()
+        if keyErr != nil {
+            continue
+        }
+        // 与本地密钥相同的检查: 曲线与算法一致、RSA 密钥长度足够
+        if _, publicKey, keyErr = checkKey(method, publicKey); keyErr != nil {
+            continue
+        }
+        keys[jwk.Kid] = remoteKey{method: method, public: publicKey}
+    }
+
+    r.mu.Lock()
+    r.keys = keys
+    r.fetchedAt = r.now()
+    r.mu.Unlock()
+    return nil
+}
+
+// refreshIfAllowed 距上次拉取超过最小间隔时重新拉取
+func (r *RemoteJWKS) refreshIfAllowed(ctx context.Context) {
+    r.refreshMu.Lock()
+    defer r.refreshMu.Unlock()
+    r.mu.RLock()
+    allowed := r.attemptedAt.IsZero() || r.now().Sub(r.attemptedAt) >= r.minRefreshInterval
+    r.mu.RUnlock()
+    if allowed {
+        _ = r.fetch(ctx)
+    }
+}
+
+// lookup 查找 kid 对应的密钥，kid 为空时返回所有密钥; stale 表示缓存已过期
+func (r *RemoteJWKS) lookup(kid string, hasKid bool) (keys []remoteKey, stale bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    stale = r.fetchedAt.IsZero() || r.now().Sub(r.fetchedAt) >= r.refreshInterval
+    if hasKid {
+        if key, ok := r.keys[kid]; ok {
+            keys = append(keys, key)
+        }
+        return keys, stale
+    }
+    for _, key := range r.keys {
+        keys = append(keys, key)
+    }
+    return keys, stale
+}
+
+// VerificationKey 按 token 头部的 kid 选择验证密钥，实现 KeySet
+func (r *RemoteJWKS) VerificationKey(token *jwt.Token) (interface{}, error) {
+    kid, hasKid := token.Header["kid"].(string)
+    keys, stale := r.lookup(kid, hasKid)
+    if len(keys) == 0 || stale {
+        ctx, cancel := context.WithTimeout(context.Background(), r.refreshTimeout)
+        r.refreshIfAllowed(ctx)
+        cancel()
+        keys, _ = r.lookup(kid, hasKid)
+    }
+    keySet := jwt.VerificationKeySet{}
+    for _, key := range keys {
+        if key.method.Alg() == token.Method.Alg() {
+            keySet.Keys = append(keySet.Keys, key.public)
+        }
+    }
+    switch {
+    case len(keys) == 0:
+        return nil, errKeyNotFound
+    case len(keySet.Keys) == 0:
+        return nil, errUnexpectedSigningMethod
+    case len(keySet.Keys) == 1:
+        return keySet.Keys[0], nil
+    }
+    return keySet, nil
+}
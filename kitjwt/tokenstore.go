@@ -0,0 +1,107 @@
+package kitjwt
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// MemoryTokenStore 内存 TokenStore，适用于单实例部署与测试; 多实例部署需要使用共享存储实现 TokenStore
+type MemoryTokenStore struct {
+    mu       sync.Mutex
+    tokens   map[string]RefreshToken
+    families map[string]*memoryFamily
+}
+
+// memoryFamily family 中的 token 与撤销状态
+type memoryFamily struct {
+    ids     []string
+    revoked bool
+}
+
+var _ TokenStore = (*MemoryTokenStore)(nil)
+
+// NewMemoryTokenStore 创建内存 TokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+    return &MemoryTokenStore{
+        tokens:   make(map[string]RefreshToken),
+        families: make(map[string]*memoryFamily),
+    }
+}
+
+// Save implements TokenStore.
+func (s *MemoryTokenStore) Save(_ context.Context, token RefreshToken) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    family, ok := s.families[token.FamilyID]
+    if !ok {
+        family = &memoryFamily{}
+        s.families[token.FamilyID] = family
+    }
+    family.ids = append(family.ids, token.ID)
+    s.tokens[token.ID] = token
+    return nil
+}
+
+// Get implements TokenStore.
+func (s *MemoryTokenStore) Get(_ context.Context, id string) (RefreshToken, bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    token, ok := s.tokens[id]
+    if !ok {
+        return RefreshToken{}, false, nil
+    }
+    if family, ok := s.families[token.FamilyID]; ok && family.revoked {
+        token.Revoked = true
+    }
+    return token, true, nil
+}
+
+// MarkUsed implements TokenStore.
+func (s *MemoryTokenStore) MarkUsed(_ context.Context, id string, usedAt time.Time) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    token, ok := s.tokens[id]
+    if !ok || !token.UsedAt.IsZero() {
+        return false, nil
+    }
+    token.UsedAt = usedAt
+    s.tokens[id] = token
+    return true, nil
+}
+
+// RevokeFamily implements TokenStore.
+func (s *MemoryTokenStore) RevokeFamily(_ context.Context, familyID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    family, ok := s.families[familyID]
+    if !ok {
+        family = &memoryFamily{}
+        s.families[familyID] = family
+    }
+    family.revoked = true
+    return nil
+}
+
+// DeleteExpired 删除 before 之前过期的 token，family 中的 token 全部删除后同时删除 family; 返回删除的 token 数量
+func (s *MemoryTokenStore) DeleteExpired(before time.Time) int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    deleted := 0
+    for familyID, family := range s.families {
+        ids := family.ids[:0]
+        for _, id := range family.ids {
+            if s.tokens[id].ExpiresAt.Before(before) {
+                delete(s.tokens, id)
+                deleted++
+                continue
+            }
+            ids = append(ids, id)
+        }
+        family.ids = ids
+        if len(ids) == 0 {
+            delete(s.families, familyID)
+        }
+    }
+    return deleted
+}
@@ -35,6 +35,28 @@ func WithInterceptors(interceptors ...connect.HandlerOption) Option {
 	}
 }
 
+// WithCodec 替换默认的 kitcodec.WithProtoJSON() 编解码器
+// 示例:
+//
+//	kitrouter.WithCodec(kitcodec.WithProtoJSONOptions(kitcodec.WithUseProtoNames()))
+func WithCodec(codec connect.HandlerOption) Option {
+	return func(a *Adapter) {
+		a.codec = codec
+	}
+}
+
+// WithOpenAPI 在 path 上提供已挂载服务的 OpenAPI 3.1 文档(JSON), 服务描述从 protoregistry.GlobalFiles 中查找.
+// 自定义了编解码器时, 需要用 kitcodec.WithOpenAPIJSONOptions 传入相同的配置
+// 示例:
+//
+//	kitrouter.WithOpenAPI("/openapi.json", kitcodec.WithOpenAPIInfo("User API", "1.0.0"))
+func WithOpenAPI(path string, opts ...kitcodec.OpenAPIOption) Option {
+	return func(a *Adapter) {
+		a.openAPIPath = path
+		a.openAPIOpts = opts
+	}
+}
+
 // route 统一路由结构
 type route struct {
 	method   string
@@ -46,11 +68,14 @@ type route struct {
 
 // Adapter 路由适配器
 type Adapter struct {
+	codec            connect.HandlerOption
 	guestMiddlewares []gin.HandlerFunc
 	authMiddlewares  []gin.HandlerFunc
 	interceptors     []connect.HandlerOption
 	guestRoutes      []route
 	authRoutes       []route
+	openAPIPath      string
+	openAPIOpts      []kitcodec.OpenAPIOption
 }
 
 var (
@@ -67,13 +92,15 @@ func Bootstrap(opts ...Option) {
 	}
 	once.Do(func() {
 		instance = &Adapter{
-			interceptors: []connect.HandlerOption{kitcodec.WithProtoJSON()},
-			guestRoutes:  make([]route, 0, 50),
-			authRoutes:   make([]route, 0, 50),
+			codec:       kitcodec.WithProtoJSON(),
+			guestRoutes: make([]route, 0, 50),
+			authRoutes:  make([]route, 0, 50),
 		}
 		for _, opt := range opts {
 			opt(instance)
 		}
+		// 编解码器放在最前面, WithInterceptors 中再次设置同名编解码器时以后者为准
+		instance.interceptors = append([]connect.HandlerOption{instance.codec}, instance.interceptors...)
 		authBuilder = &RouteBuilder{adapter: instance, isAuth: true}
 		guestBuilder = &RouteBuilder{adapter: instance, isAuth: false}
 	})
@@ -180,4 +207,29 @@ func (a *Adapter) mount(engine *gin.Engine) {
 			authR.Handle(r.method, r.path, r.handler)
 		}
 	}
+	if a.openAPIPath != "" {
+		doc, err := kitcodec.GenerateOpenAPI(a.openAPIRoutes(), a.openAPIOpts...)
+		if err != nil {
+			panic("generate openapi document: " + err.Error())
+		}
+		guestR.GET(a.openAPIPath, func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json", doc)
+		})
+	}
+}
+
+// openAPIRoutes 收集已注册的 Connect 路由, 自定义路由不在文档中
+func (a *Adapter) openAPIRoutes() []kitcodec.OpenAPIRoute {
+	routes := make([]kitcodec.OpenAPIRoute, 0, len(a.guestRoutes)+len(a.authRoutes))
+	for _, r := range a.guestRoutes {
+		if !r.isCustom {
+			routes = append(routes, kitcodec.OpenAPIRoute{Method: r.method, Path: r.path})
+		}
+	}
+	for _, r := range a.authRoutes {
+		if !r.isCustom {
+			routes = append(routes, kitcodec.OpenAPIRoute{Method: r.method, Path: r.path, Auth: true})
+		}
+	}
+	return routes
 }